@@ -44,7 +44,7 @@ func main() {
 		log.Fatalf("Error creating stream: %v", err)
 	}
 
-	// Track last OHLC time for each symbol
+	// Track last closed OHLC time for each symbol and interval
 	lastOHLCTime := make(map[string]time.Time)
 
 	// Receive and print streaming updates
@@ -60,14 +60,21 @@ func main() {
 		// Print the received OHLC data
 		openTime := time.UnixMilli(ohlc.OpenTime)
 		closeTime := time.UnixMilli(ohlc.CloseTime)
+		key := ohlc.Symbol + "@" + ohlc.Interval
 		interval := ""
-		if lastTime, ok := lastOHLCTime[ohlc.Symbol]; ok {
-			interval = fmt.Sprintf(" (Interval: %v)", openTime.Sub(lastTime).Round(time.Second))
+		state := "live"
+		if ohlc.IsClosed {
+			state = "closed"
+			if lastTime, ok := lastOHLCTime[key]; ok {
+				interval = fmt.Sprintf(" (Interval: %v)", openTime.Sub(lastTime).Round(time.Second))
+			}
+			lastOHLCTime[key] = openTime
 		}
-		lastOHLCTime[ohlc.Symbol] = openTime
 
-		fmt.Printf("[%s] %s - Open: %.2f, High: %.2f, Low: %.2f, Close: %.2f, Volume: %.2f (Period: %s - %s)%s\n",
+		fmt.Printf("[%s %s %s] %s - Open: %s, High: %s, Low: %s, Close: %s, Volume: %s (Period: %s - %s)%s\n",
 			ohlc.Symbol,
+			ohlc.Interval,
+			state,
 			openTime.Format("15:04:05"),
 			ohlc.Open,
 			ohlc.High,
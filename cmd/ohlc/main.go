@@ -5,19 +5,36 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // time zones for calendar alignment in minimal images
 
 	"github.com/azanium/ohlc/conf"
 	"github.com/azanium/ohlc/internal/candlestick"
 	"github.com/azanium/ohlc/internal/proto/proto"
+	"github.com/azanium/ohlc/internal/retention"
 	"github.com/azanium/ohlc/internal/service"
+	"github.com/azanium/ohlc/internal/storage"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc"
 )
 
 func main() {
+	// Backfill rolled up candles instead of running the service
+	if len(os.Args) > 1 && os.Args[1] == "rollup" {
+		runRollup(os.Args[2:])
+		return
+	}
+
+	// Apply or revert schema migrations instead of running the service
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Create a context with cancellation for coordinated shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -26,15 +43,12 @@ func main() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// Initialize storage with environment variables or defaults
-	host := conf.GetConf().Postgres.Master.Address
-	user := conf.GetConf().Postgres.Master.Username
-	password := conf.GetConf().Postgres.Master.Password
-	dbname := conf.GetConf().Postgres.Master.Database
-	port := conf.GetConf().Postgres.Master.Port
-	sslMode := conf.GetConf().Postgres.Master.SSLMode
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
-		host, user, password, dbname, port, sslMode)
+	// Candle intervals to aggregate, defaulting to 1m candles
+	intervals := []candlestick.Interval{candlestick.Interval1m}
+	if len(conf.GetConf().Aggregation.Intervals) > 0 {
+		intervals = parseIntervals(conf.GetConf().Aggregation.Intervals)
+	}
+
 	// Service configuration
 	config := service.Config{
 		Symbols: []candlestick.Symbol{
@@ -42,10 +56,31 @@ func main() {
 			candlestick.ETHUSDT,
 			candlestick.PEPEUSDT,
 		},
-		Interval:       time.Minute,
-		StorageDSN:     dsn,
-		MaxSubscribers: 100,
-		ChannelSize:    1000,
+		Intervals:       intervals,
+		Rollups:         parseIntervals(conf.GetConf().Aggregation.Rollups),
+		CloseDelay:      time.Duration(conf.GetConf().Aggregation.CloseDelayMs) * time.Millisecond,
+		GapFill:         conf.GetConf().Aggregation.GapFill,
+		UpdateInterval:  time.Duration(conf.GetConf().Aggregation.UpdateIntervalMs) * time.Millisecond,
+		AllowedLateness: time.Duration(conf.GetConf().Aggregation.AllowedLatenessMs) * time.Millisecond,
+		Precisions:      precisions(),
+		Calendar:        newCalendar(),
+		Bars:            bars(),
+		HeikinAshi:      parseIntervals(conf.GetConf().Aggregation.HeikinAshi),
+		Indicators:      indicators(),
+		LiveIndicators:  conf.GetConf().Aggregation.LiveIndicators,
+		Footprint:       footprint(),
+		Shards:          conf.GetConf().Aggregation.Shards,
+		TickWriter: candlestick.TickWriterConfig{
+			BufferSize:    conf.GetConf().Aggregation.TickBufferSize,
+			BatchSize:     conf.GetConf().Aggregation.TickBatchSize,
+			FlushInterval: time.Duration(conf.GetConf().Aggregation.TickFlushMs) * time.Millisecond,
+			MaxRetries:    conf.GetConf().Aggregation.TickMaxRetries,
+		},
+		SnapshotInterval: time.Duration(conf.GetConf().Aggregation.SnapshotIntervalMs) * time.Millisecond,
+		Retention:        retentionConfig(),
+		Storage:          storageConfig(),
+		MaxSubscribers:   100,
+		ChannelSize:      1000,
 	}
 
 	log.Printf("Starting OHLC service with configuration: %+v", config)
@@ -76,6 +111,18 @@ func main() {
 		}
 	}()
 
+	// Serve expvar metrics, such as the tick writer backlog, on /debug/vars
+	var metricsServer *http.Server
+	if address := conf.GetConf().Server.MetricsAddress; address != "" {
+		metricsServer = &http.Server{Addr: address}
+		go func() {
+			log.Printf("Starting metrics server on %s", address)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
 	// Wait for shutdown signal
 	sig := <-sigCh
 	log.Printf("Received signal %v, initiating graceful shutdown...", sig)
@@ -96,6 +143,10 @@ func main() {
 		grpcServer.GracefulStop()
 		log.Println("gRPC server stopped")
 
+		if metricsServer != nil {
+			metricsServer.Shutdown(shutdownCtx)
+		}
+
 		// Stop the service
 		log.Println("Stopping OHLC service...")
 		if err := svc.Stop(); err != nil {
@@ -114,3 +165,154 @@ func main() {
 	}
 
 }
+
+// postgresDSN builds the DSN of the master database from the configuration
+func postgresDSN() string {
+	return connectionDSN(conf.GetConf().Postgres.Master)
+}
+
+// connectionDSN builds the DSN of a configured database, or returns an empty DSN if it has no
+// address
+func connectionDSN(db conf.ConnectingConfig) string {
+	if db.Address == "" {
+		return ""
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		db.Address, db.Username, db.Password, db.Database, db.Port, db.SSLMode)
+}
+
+// postgresConfig returns the configured master and follower databases and connection settings
+func postgresConfig() storage.PostgreSQLConfig {
+	postgres := conf.GetConf().Postgres
+	return storage.PostgreSQLConfig{
+		MasterDSN:       postgresDSN(),
+		FollowerDSN:     connectionDSN(postgres.Follower),
+		MaxOpenConns:    postgres.MaxOpenConns,
+		MaxIdleConns:    postgres.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(postgres.ConnMaxLifetime) * time.Second,
+		MaxRetry:        postgres.MaxRetry,
+		Migrate:         postgres.AutoMigrate,
+	}
+}
+
+// storageConfig returns the configured storage backend and its database
+func storageConfig() storage.Config {
+	config := conf.GetConf().Storage
+	return storage.Config{
+		Driver:   config.Driver,
+		Postgres: postgresConfig(),
+		SQLite: storage.SQLiteConfig{
+			Path:    config.SQLite.Path,
+			Migrate: config.SQLite.AutoMigrate,
+		},
+	}
+}
+
+// parseIntervals parses configured interval names, exiting on invalid ones
+func parseIntervals(intervalStrs []string) []candlestick.Interval {
+	intervals := make([]candlestick.Interval, 0, len(intervalStrs))
+	for _, intervalStr := range intervalStrs {
+		interval, err := candlestick.ParseInterval(intervalStr)
+		if err != nil {
+			log.Fatalf("Invalid aggregation interval: %v", err)
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// newCalendar returns the configured calendar, exiting on an invalid time zone or week start
+func newCalendar() candlestick.Calendar {
+	calendar, err := candlestick.NewCalendar(conf.GetConf().Aggregation.Timezone, conf.GetConf().Aggregation.WeekStart)
+	if err != nil {
+		log.Fatalf("Invalid aggregation calendar: %v", err)
+	}
+	return calendar
+}
+
+// bars returns the configured information-driven bars per symbol, exiting on invalid ones
+func bars() map[candlestick.Symbol][]candlestick.BarSpec {
+	bars := make(map[candlestick.Symbol][]candlestick.BarSpec)
+	for symbol, specStrs := range conf.GetConf().Aggregation.Bars {
+		for _, specStr := range specStrs {
+			spec, err := candlestick.ParseBarSpec(specStr)
+			if err != nil {
+				log.Fatalf("Invalid bar for %s: %v", symbol, err)
+			}
+			bars[candlestick.Symbol(symbol)] = append(bars[candlestick.Symbol(symbol)], spec)
+		}
+	}
+	return bars
+}
+
+// precisions returns the per-symbol precision, overriding the exchange defaults of the built-in
+// symbols with the configured ones
+func precisions() candlestick.Precisions {
+	precisions := candlestick.Precisions{}
+	for symbol, precision := range candlestick.DefaultPrecisions {
+		precisions[symbol] = precision
+	}
+	for symbol, precision := range conf.GetConf().Aggregation.Precision {
+		precisions[candlestick.Symbol(symbol)] = candlestick.Precision{Price: precision.Price, Quantity: precision.Quantity}
+	}
+	return precisions
+}
+
+// indicators returns the configured technical indicators, exiting on invalid ones
+func indicators() []candlestick.IndicatorSpec {
+	specs := make([]candlestick.IndicatorSpec, 0, len(conf.GetConf().Aggregation.Indicators))
+	for _, specStr := range conf.GetConf().Aggregation.Indicators {
+		spec, err := candlestick.ParseIndicatorSpec(specStr)
+		if err != nil {
+			log.Fatalf("Invalid indicator: %v", err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// footprint returns the configured footprint intervals, tick sizes and value area, exiting on
+// invalid ones
+func footprint() candlestick.FootprintConfig {
+	footprintConf := conf.GetConf().Aggregation.Footprint
+	config := candlestick.FootprintConfig{
+		Intervals: parseIntervals(footprintConf.Intervals),
+		TickSizes: make(map[candlestick.Symbol]decimal.Decimal),
+	}
+	for symbol, tickSizeStr := range footprintConf.TickSize {
+		tickSize, err := decimal.NewFromString(tickSizeStr)
+		if err != nil || !tickSize.IsPositive() {
+			log.Fatalf("Invalid footprint tick size for %s: %q", symbol, tickSizeStr)
+		}
+		config.TickSizes[candlestick.Symbol(symbol)] = tickSize
+	}
+	if footprintConf.ValueArea != "" {
+		valueArea, err := decimal.NewFromString(footprintConf.ValueArea)
+		if err != nil || !valueArea.IsPositive() || valueArea.GreaterThan(decimal.NewFromInt(1)) {
+			log.Fatalf("Invalid footprint value area: %q", footprintConf.ValueArea)
+		}
+		config.ValueArea = valueArea
+	}
+	return config
+}
+
+// retentionConfig returns the configured retention of ticks and candles, exiting on invalid
+// intervals
+func retentionConfig() retention.Config {
+	retentionConf := conf.GetConf().Retention
+	config := retention.Config{
+		Interval:     time.Duration(retentionConf.IntervalMs) * time.Millisecond,
+		TickDays:     retentionConf.TickDays,
+		ArchiveDir:   retentionConf.TickArchiveDir,
+		CandleMonths: make(map[candlestick.Interval]int),
+		DryRun:       retentionConf.DryRun,
+	}
+	for intervalStr, months := range retentionConf.CandleMonths {
+		interval, err := candlestick.ParseInterval(intervalStr)
+		if err != nil {
+			log.Fatalf("Invalid retention interval: %v", err)
+		}
+		config.CandleMonths[interval] = months
+	}
+	return config
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/azanium/ohlc/internal/storage"
+)
+
+// runMigrate applies, reverts or lists the embedded schema migrations of the configured database, e.g.
+//
+//	ohlc migrate up
+//	ohlc migrate down -to 1
+//	ohlc migrate status
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: ohlc migrate up|down|status [-to version]")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	to := flags.Int("to", -1, "version to migrate up or down to, up defaults to the latest")
+	flags.Parse(args[1:])
+
+	migrator, err := storage.OpenMigrator(storageConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		if *to < 0 {
+			*to = 0
+		}
+		if err := migrator.Up(*to); err != nil {
+			log.Fatalf("Failed to migrate up: %v", err)
+		}
+	case "down":
+		// Reverting may drop data, so the target version is never implied
+		if *to < 0 {
+			log.Fatalf("migrate down needs -to, the version to revert to, e.g. -to 0 to revert every migration")
+		}
+		if err := migrator.Down(*to); err != nil {
+			log.Fatalf("Failed to migrate down: %v", err)
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, migration := range status {
+			applied := "pending"
+			if migration.AppliedAt != nil {
+				applied = "applied " + migration.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(os.Stdout, "%04d %-30s %s\n", migration.Version, migration.Name, applied)
+		}
+		return
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		log.Fatalf("Failed to read the schema version: %v", err)
+	}
+	log.Printf("Database schema is at version %d of %d", version, migrator.Latest())
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/azanium/ohlc/internal/storage"
+)
+
+// runRollup backfills higher-interval candles from the candles stored in the ohlcs table, e.g.
+//
+//	ohlc rollup -symbols BTCUSDT,ETHUSDT -from 1m -to 5m,1h,1d -start 2025-01-01T00:00:00Z -end 2025-02-01T00:00:00Z
+func runRollup(args []string) {
+	flags := flag.NewFlagSet("rollup", flag.ExitOnError)
+	symbolsFlag := flags.String("symbols", "BTCUSDT,ETHUSDT,PEPEUSDT", "comma separated symbols to backfill")
+	fromFlag := flags.String("from", "1m", "interval of the stored candles to roll up")
+	toFlag := flags.String("to", "5m,1h,1d", "comma separated intervals to build")
+	startFlag := flags.String("start", "", "RFC 3339 start of the backfill, aligned to each target interval")
+	endFlag := flags.String("end", "", "RFC 3339 end of the backfill, defaults to now")
+	dryRun := flags.Bool("dry-run", false, "log the rolled up candles without storing them")
+	flags.Parse(args)
+
+	from, err := candlestick.ParseInterval(*fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid source interval: %v", err)
+	}
+	targets := parseIntervals(strings.Split(*toFlag, ","))
+
+	start, err := time.Parse(time.RFC3339, *startFlag)
+	if err != nil {
+		log.Fatalf("Invalid start time: %v", err)
+	}
+	end := time.Now().UTC()
+	if *endFlag != "" {
+		if end, err = time.Parse(time.RFC3339, *endFlag); err != nil {
+			log.Fatalf("Invalid end time: %v", err)
+		}
+	}
+
+	calendar := newCalendar()
+
+	store, err := storage.Open(storageConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer store.Close()
+
+	for _, symbolStr := range strings.Split(*symbolsFlag, ",") {
+		symbol := candlestick.Symbol(symbolStr)
+		for _, target := range targets {
+			candles, err := store.GetRange(symbol, from, calendar.Truncate(target, start), end)
+			if err != nil {
+				log.Fatalf("Failed to load %s candles for %s: %v", from, symbol, err)
+			}
+
+			rolled := candlestick.RollupCandles(candles, target, end, precisions(), calendar)
+			for _, ohlc := range rolled {
+				if *dryRun {
+					log.Printf("Rolled up %s candle for symbol=%s at time=%s: open=%s, high=%s, low=%s, close=%s, volume=%s",
+						ohlc.Interval, ohlc.Symbol, ohlc.OpenTime.Format(time.RFC3339), ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close, ohlc.Volume)
+					continue
+				}
+				if err := store.Store(ohlc); err != nil {
+					log.Fatalf("Failed to store %s candle for %s: %v", target, symbol, err)
+				}
+			}
+			log.Printf("Rolled up %d %s candles into %d %s candles for symbol=%s", len(candles), from, len(rolled), target, symbol)
+		}
+	}
+}
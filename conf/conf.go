@@ -19,9 +19,64 @@ var (
 )
 
 type Config struct {
-	Env      string
-	Server   Server   `yaml:"server"`
-	Postgres Postgres `yaml:"postgres"`
+	Env         string
+	Server      Server      `yaml:"server"`
+	Aggregation Aggregation `yaml:"aggregation"`
+	Storage     Storage     `yaml:"storage"`
+	Postgres    Postgres    `yaml:"postgres"`
+	Retention   Retention   `yaml:"retention"`
+}
+
+type Aggregation struct {
+	Intervals          []string             `yaml:"intervals"`
+	Rollups            []string             `yaml:"rollups"`
+	Timezone           string               `yaml:"timezone"`
+	WeekStart          string               `yaml:"week_start"`
+	CloseDelayMs       int                  `yaml:"close_delay_ms"`
+	GapFill            bool                 `yaml:"gap_fill"`
+	UpdateIntervalMs   int                  `yaml:"update_interval_ms"`
+	AllowedLatenessMs  int                  `yaml:"allowed_lateness_ms"`
+	SnapshotIntervalMs int                  `yaml:"snapshot_interval_ms"`
+	Shards             int                  `yaml:"shards"`
+	TickBufferSize     int                  `yaml:"tick_buffer_size"`
+	TickBatchSize      int                  `yaml:"tick_batch_size"`
+	TickFlushMs        int                  `yaml:"tick_flush_ms"`
+	TickMaxRetries     int                  `yaml:"tick_max_retries"`
+	Precision          map[string]Precision `yaml:"precision"`
+	Bars               map[string][]string  `yaml:"bars"`
+	HeikinAshi         []string             `yaml:"heikin_ashi"`
+	Indicators         []string             `yaml:"indicators"`
+	LiveIndicators     bool                 `yaml:"live_indicators"`
+	Footprint          Footprint            `yaml:"footprint"`
+}
+
+type Footprint struct {
+	Intervals []string          `yaml:"intervals"`
+	TickSize  map[string]string `yaml:"tick_size"`
+	ValueArea string            `yaml:"value_area"`
+}
+
+type Retention struct {
+	IntervalMs     int            `yaml:"interval_ms"`
+	DryRun         bool           `yaml:"dry_run"`
+	TickDays       int            `yaml:"tick_days"`
+	TickArchiveDir string         `yaml:"tick_archive_dir"`
+	CandleMonths   map[string]int `yaml:"candle_months"`
+}
+
+type Precision struct {
+	Price    int32 `yaml:"price"`
+	Quantity int32 `yaml:"quantity"`
+}
+
+type Storage struct {
+	Driver string `yaml:"driver"`
+	SQLite SQLite `yaml:"sqlite"`
+}
+
+type SQLite struct {
+	Path        string `yaml:"path"`
+	AutoMigrate bool   `yaml:"auto_migrate"`
 }
 
 type Postgres struct {
@@ -31,6 +86,7 @@ type Postgres struct {
 	MaxIdleConns    int              `yaml:"max_idle_conns"`
 	MaxRetry        int              `yaml:"max_retry"`
 	ConnMaxLifetime int              `yaml:"conn_max_lifetime"`
+	AutoMigrate     bool             `yaml:"auto_migrate"`
 }
 
 type ConnectingConfig struct {
@@ -43,13 +99,14 @@ type ConnectingConfig struct {
 }
 
 type Server struct {
-	Service       string `yaml:"service"`
-	Address       string `yaml:"address"`
-	LogLevel      string `yaml:"log_level"`
-	LogFileName   string `yaml:"log_file_name"`
-	LogMaxSize    int    `yaml:"log_max_size"`
-	LogMaxBackups int    `yaml:"log_max_backups"`
-	LogMaxAge     int    `yaml:"log_max_age"`
+	Service        string `yaml:"service"`
+	Address        string `yaml:"address"`
+	MetricsAddress string `yaml:"metrics_address"`
+	LogLevel       string `yaml:"log_level"`
+	LogFileName    string `yaml:"log_file_name"`
+	LogMaxSize     int    `yaml:"log_max_size"`
+	LogMaxBackups  int    `yaml:"log_max_backups"`
+	LogMaxAge      int    `yaml:"log_max_age"`
 }
 
 // GetConf gets configuration instance
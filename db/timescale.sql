@@ -0,0 +1,149 @@
+-- Optional TimescaleDB storage mode, run once after `ohlc migrate up` on a database with the
+-- TimescaleDB 2.11 or later extension available:
+--
+--   psql -U postgres -d ohlc -f db/timescale.sql
+--
+-- It turns ticks and ohlcs into hypertables partitioned by time, compresses older chunks, and
+-- derives higher interval candles from the 1m candles with continuous aggregates named
+-- ohlcs_<interval>. The service detects the aggregates on start and reads those intervals from
+-- them. Buckets are aligned to UTC, so the aggregates suit a UTC aggregation timezone only. Their
+-- close time is the exclusive end of the bucket, like that of candles built from ticks; aggregates
+-- created by earlier versions of this script ended a millisecond before it and must be dropped
+-- before running it again to pick this up.
+CREATE EXTENSION IF NOT EXISTS timescaledb;
+
+-- Unique indexes of hypertables must include the time column, which the UUID keys do not, while
+-- the price and quantity indexes slow inserts without serving any query
+ALTER TABLE ticks DROP CONSTRAINT IF EXISTS ticks_pkey;
+ALTER TABLE ohlcs DROP CONSTRAINT IF EXISTS ohlcs_pkey;
+DROP INDEX IF EXISTS idx_tick_price;
+DROP INDEX IF EXISTS idx_tick_quantity;
+
+SELECT create_hypertable('ticks', 'timestamp', chunk_time_interval => INTERVAL '1 day', migrate_data => true, if_not_exists => true);
+SELECT create_hypertable('ohlcs', 'open_time', chunk_time_interval => INTERVAL '7 days', migrate_data => true, if_not_exists => true);
+
+-- Chunks are compressed once late ticks can no longer revise their candles
+ALTER TABLE ticks SET (timescaledb.compress, timescaledb.compress_segmentby = 'symbol', timescaledb.compress_orderby = 'timestamp DESC, trade_id DESC');
+ALTER TABLE ohlcs SET (timescaledb.compress, timescaledb.compress_segmentby = 'symbol, "interval"', timescaledb.compress_orderby = 'open_time DESC');
+SELECT add_compression_policy('ticks', INTERVAL '7 days', if_not_exists => true);
+SELECT add_compression_policy('ohlcs', INTERVAL '30 days', if_not_exists => true);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS ohlcs_5m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT symbol,
+       time_bucket(INTERVAL '5 minutes', open_time) AS open_time,
+       time_bucket(INTERVAL '5 minutes', open_time) + INTERVAL '5 minutes' AS close_time,
+       first(open, open_time) AS open,
+       max(high) AS high,
+       min(low) AS low,
+       last(close, open_time) AS close,
+       sum(volume) AS volume,
+       sum(quote_volume) AS quote_volume,
+       sum(taker_buy_volume) AS taker_buy_volume,
+       sum(taker_buy_quote_volume) AS taker_buy_quote_volume,
+       sum(quote_volume) / NULLIF(sum(volume), 0) AS vwap,
+       sum(trades) AS trades,
+       min(first_trade_id) AS first_trade_id,
+       max(last_trade_id) AS last_trade_id,
+       bool_and(synthetic) AS synthetic
+FROM ohlcs
+WHERE "interval" = '1m'
+GROUP BY symbol, time_bucket(INTERVAL '5 minutes', open_time)
+WITH NO DATA;
+SELECT add_continuous_aggregate_policy('ohlcs_5m', start_offset => INTERVAL '3 days', end_offset => INTERVAL '5 minutes', schedule_interval => INTERVAL '5 minutes', if_not_exists => true);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS ohlcs_15m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT symbol,
+       time_bucket(INTERVAL '15 minutes', open_time) AS open_time,
+       time_bucket(INTERVAL '15 minutes', open_time) + INTERVAL '15 minutes' AS close_time,
+       first(open, open_time) AS open,
+       max(high) AS high,
+       min(low) AS low,
+       last(close, open_time) AS close,
+       sum(volume) AS volume,
+       sum(quote_volume) AS quote_volume,
+       sum(taker_buy_volume) AS taker_buy_volume,
+       sum(taker_buy_quote_volume) AS taker_buy_quote_volume,
+       sum(quote_volume) / NULLIF(sum(volume), 0) AS vwap,
+       sum(trades) AS trades,
+       min(first_trade_id) AS first_trade_id,
+       max(last_trade_id) AS last_trade_id,
+       bool_and(synthetic) AS synthetic
+FROM ohlcs
+WHERE "interval" = '1m'
+GROUP BY symbol, time_bucket(INTERVAL '15 minutes', open_time)
+WITH NO DATA;
+SELECT add_continuous_aggregate_policy('ohlcs_15m', start_offset => INTERVAL '3 days', end_offset => INTERVAL '15 minutes', schedule_interval => INTERVAL '5 minutes', if_not_exists => true);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS ohlcs_1h
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT symbol,
+       time_bucket(INTERVAL '1 hour', open_time) AS open_time,
+       time_bucket(INTERVAL '1 hour', open_time) + INTERVAL '1 hour' AS close_time,
+       first(open, open_time) AS open,
+       max(high) AS high,
+       min(low) AS low,
+       last(close, open_time) AS close,
+       sum(volume) AS volume,
+       sum(quote_volume) AS quote_volume,
+       sum(taker_buy_volume) AS taker_buy_volume,
+       sum(taker_buy_quote_volume) AS taker_buy_quote_volume,
+       sum(quote_volume) / NULLIF(sum(volume), 0) AS vwap,
+       sum(trades) AS trades,
+       min(first_trade_id) AS first_trade_id,
+       max(last_trade_id) AS last_trade_id,
+       bool_and(synthetic) AS synthetic
+FROM ohlcs
+WHERE "interval" = '1m'
+GROUP BY symbol, time_bucket(INTERVAL '1 hour', open_time)
+WITH NO DATA;
+SELECT add_continuous_aggregate_policy('ohlcs_1h', start_offset => INTERVAL '3 days', end_offset => INTERVAL '1 hour', schedule_interval => INTERVAL '1 hour', if_not_exists => true);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS ohlcs_4h
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT symbol,
+       time_bucket(INTERVAL '4 hours', open_time) AS open_time,
+       time_bucket(INTERVAL '4 hours', open_time) + INTERVAL '4 hours' AS close_time,
+       first(open, open_time) AS open,
+       max(high) AS high,
+       min(low) AS low,
+       last(close, open_time) AS close,
+       sum(volume) AS volume,
+       sum(quote_volume) AS quote_volume,
+       sum(taker_buy_volume) AS taker_buy_volume,
+       sum(taker_buy_quote_volume) AS taker_buy_quote_volume,
+       sum(quote_volume) / NULLIF(sum(volume), 0) AS vwap,
+       sum(trades) AS trades,
+       min(first_trade_id) AS first_trade_id,
+       max(last_trade_id) AS last_trade_id,
+       bool_and(synthetic) AS synthetic
+FROM ohlcs
+WHERE "interval" = '1m'
+GROUP BY symbol, time_bucket(INTERVAL '4 hours', open_time)
+WITH NO DATA;
+SELECT add_continuous_aggregate_policy('ohlcs_4h', start_offset => INTERVAL '7 days', end_offset => INTERVAL '4 hours', schedule_interval => INTERVAL '1 hour', if_not_exists => true);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS ohlcs_1d
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT symbol,
+       time_bucket(INTERVAL '1 day', open_time) AS open_time,
+       time_bucket(INTERVAL '1 day', open_time) + INTERVAL '1 day' AS close_time,
+       first(open, open_time) AS open,
+       max(high) AS high,
+       min(low) AS low,
+       last(close, open_time) AS close,
+       sum(volume) AS volume,
+       sum(quote_volume) AS quote_volume,
+       sum(taker_buy_volume) AS taker_buy_volume,
+       sum(taker_buy_quote_volume) AS taker_buy_quote_volume,
+       sum(quote_volume) / NULLIF(sum(volume), 0) AS vwap,
+       sum(trades) AS trades,
+       min(first_trade_id) AS first_trade_id,
+       max(last_trade_id) AS last_trade_id,
+       bool_and(synthetic) AS synthetic
+FROM ohlcs
+WHERE "interval" = '1m'
+GROUP BY symbol, time_bucket(INTERVAL '1 day', open_time)
+WITH NO DATA;
+SELECT add_continuous_aggregate_policy('ohlcs_1d', start_offset => INTERVAL '7 days', end_offset => INTERVAL '1 day', schedule_interval => INTERVAL '1 hour', if_not_exists => true);
@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/azanium/ohlc/internal/candlestick"
 	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
 )
 
 // Custom error types for better error handling
@@ -374,26 +374,30 @@ func (c *Client) handleMessages() {
 
 			// Convert message to Tick with error handling
 			symbol := candlestick.Symbol(aggTradeMsg.Symbol)
-			price, err := strconv.ParseFloat(aggTradeMsg.Price, 64)
+			price, err := decimal.NewFromString(aggTradeMsg.Price)
 			if err != nil {
 				log.Printf("ERROR: Failed parsing price for %s: %v", symbol, err)
 				continue
 			}
 
-			quantity, err := strconv.ParseFloat(aggTradeMsg.Quantity, 64)
+			quantity, err := decimal.NewFromString(aggTradeMsg.Quantity)
 			if err != nil {
 				log.Printf("ERROR: Failed parsing quantity for %s: %v", symbol, err)
 				continue
 			}
 
 			tick := candlestick.Tick{
-				Symbol:    symbol,
-				Price:     price,
-				Quantity:  quantity,
-				Timestamp: time.Unix(0, aggTradeMsg.Timestamp*int64(time.Millisecond)),
+				Symbol:       symbol,
+				Price:        price,
+				Quantity:     quantity,
+				Timestamp:    time.Unix(0, aggTradeMsg.Timestamp*int64(time.Millisecond)),
+				TradeID:      aggTradeMsg.ID,
+				FirstTradeID: aggTradeMsg.FirstID,
+				LastTradeID:  aggTradeMsg.LastID,
+				IsBuyerMaker: aggTradeMsg.IsBuyerMaker,
 			}
 
-			log.Printf("INFO: Created tick for %s: price=%.2f quantity=%.2f timestamp=%s",
+			log.Printf("INFO: Created tick for %s: price=%s quantity=%s timestamp=%s",
 				symbol, price, quantity, tick.Timestamp.Format(time.RFC3339))
 
 			// Distribute tick to handlers
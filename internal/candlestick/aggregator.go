@@ -1,116 +1,585 @@
 package candlestick
 
 import (
-	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// AggregatorConfig holds aggregator configuration
+type AggregatorConfig struct {
+	// Intervals lists the candle time frames maintained for every symbol
+	Intervals []Interval
+	// GapFill emits flat, zero-volume candles for intervals without any trade
+	GapFill bool
+	// AllowedLateness is how long after its close a candle still accepts late ticks, re-emitting
+	// it as a revision; older ticks are dropped
+	AllowedLateness time.Duration
+	// Precisions holds the per-symbol precision VWAP is rounded to
+	Precisions Precisions
+	// Calendar aligns candles to the boundaries of its time zone and week start
+	Calendar Calendar
+	// Bars lists the information-driven bars maintained per symbol, alongside the time candles
+	Bars map[Symbol][]BarSpec
+}
+
+// AggregatorStats holds counters about late tick handling
+type AggregatorStats struct {
+	Revisions    int64 // candles re-emitted after a late tick corrected them
+	DroppedTicks int64 // ticks older than the allowed lateness
+}
+
+// candleKey identifies an in-progress candle
+type candleKey struct {
+	symbol   Symbol
+	interval Interval
+}
+
 // aggregator implements the Aggregator interface for OHLC data
 type aggregator struct {
-	mu       sync.RWMutex
-	current  map[Symbol]*OHLC
-	interval time.Duration
-	storage  Storage
+	mu              sync.RWMutex
+	current         map[candleKey]*OHLC
+	last            map[candleKey]*OHLC   // last completed candle
+	history         map[candleKey][]*OHLC // completed candles still accepting late ticks, by open time
+	dirty           map[candleKey]bool    // in-progress candles changed since the last Updates call
+	watermarks      map[Symbol]time.Time  // latest tick timestamp seen per symbol
+	intervals       []Interval
+	gapFill         bool
+	allowedLateness time.Duration
+	precisions      Precisions
+	calendar        Calendar
+	bars            map[Symbol][]BarSpec
+	stats           AggregatorStats
 }
 
-// NewAggregator creates a new OHLC aggregator with the specified interval
-func NewAggregator(interval time.Duration, storage Storage) Aggregator {
+// NewAggregator creates a new OHLC aggregator maintaining candles for each configured interval
+func NewAggregator(config AggregatorConfig) Aggregator {
 	return &aggregator{
-		current:  make(map[Symbol]*OHLC),
-		interval: interval,
-		storage:  storage,
+		current:         make(map[candleKey]*OHLC),
+		last:            make(map[candleKey]*OHLC),
+		history:         make(map[candleKey][]*OHLC),
+		dirty:           make(map[candleKey]bool),
+		watermarks:      make(map[Symbol]time.Time),
+		intervals:       config.Intervals,
+		gapFill:         config.GapFill,
+		allowedLateness: config.AllowedLateness,
+		precisions:      config.Precisions,
+		calendar:        config.Calendar,
+		bars:            config.Bars,
 	}
 }
 
-// Process handles a new tick and returns a completed OHLC if available
-func (a *aggregator) Process(tick Tick) (*OHLC, error) {
-	log.Printf("Processing tick: symbol=%s, price=%.2f, quantity=%.2f, timestamp=%s",
-		tick.Symbol, tick.Price, tick.Quantity, tick.Timestamp.Format(time.RFC3339))
+// Process handles a new tick and returns the OHLCs it completed
+func (a *aggregator) Process(tick Tick) ([]*OHLC, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.process(tick, false), nil
+}
+
+// Replay applies a stored tick without persisting it again, skipping ticks the in-progress
+// candles already contain, and returns the OHLCs it completed
+func (a *aggregator) Replay(tick Tick) []*OHLC {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	// Store the tick in the database
-	if err := a.storage.StoreTick(&tick); err != nil {
-		log.Printf("Error storing tick: %v", err)
-		return nil, fmt.Errorf("failed to store tick: %v", err)
-	}
+	return a.process(tick, true)
+}
 
+// Restore replaces the in-progress candles with copies of candles, typically loaded from a snapshot
+func (a *aggregator) Restore(candles []*OHLC) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Get or create current OHLC for the symbol
-	ohlc, exists := a.current[tick.Symbol]
-	if !exists || a.shouldStartNewCandle(tick.Timestamp, ohlc) {
+	clear(a.current)
+	clear(a.dirty)
+	for _, ohlc := range candles {
+		copy := *ohlc
+		copy.IsClosed = false
+		a.current[candleKey{symbol: ohlc.Symbol, interval: ohlc.Interval}] = &copy
+	}
+	log.Printf("Restored %d in-progress candles", len(candles))
+}
+
+// process applies a tick to the candles of every interval and returns the OHLCs it completed
+func (a *aggregator) process(tick Tick, replay bool) []*OHLC {
+	if tick.Timestamp.After(a.watermarks[tick.Symbol]) {
+		a.watermarks[tick.Symbol] = tick.Timestamp
+	}
+
+	var completed []*OHLC
+	for _, interval := range a.intervals {
+		key := candleKey{symbol: tick.Symbol, interval: interval}
+		if replay && a.contains(key, tick) {
+			continue
+		}
+		completed = append(completed, a.update(key, tick)...)
+	}
+	for _, spec := range a.bars[tick.Symbol] {
+		key := candleKey{symbol: tick.Symbol, interval: spec.Interval()}
+		if replay && a.containsBar(key, tick) {
+			continue
+		}
+		if spec.Type == BarTypeRenko {
+			completed = append(completed, a.updateRenko(key, spec, tick)...)
+		} else if ohlc := a.updateBar(key, spec, tick); ohlc != nil {
+			completed = append(completed, ohlc)
+		}
+	}
+
+	return completed
+}
+
+// updateBar applies a tick to the bar identified by key and returns the bar if the tick
+// completed it; bars are filled in arrival order, late ticks included
+func (a *aggregator) updateBar(key candleKey, spec BarSpec, tick Tick) *OHLC {
+	ohlc, exists := a.current[key]
+	if !exists {
+		ohlc = a.newBar(key, tick)
+		a.current[key] = ohlc
+	} else {
+		addTick(ohlc, tick, a.precisions.For(key.symbol))
+		if tick.Timestamp.After(ohlc.CloseTime) {
+			ohlc.CloseTime = tick.Timestamp
+		}
+	}
+	a.dirty[key] = true
+
+	if !spec.full(ohlc) {
+		return nil
+	}
+	log.Printf("Closing %s bar for symbol=%s at time=%s", key.interval, key.symbol, ohlc.CloseTime.Format(time.RFC3339))
+	ohlc.IsClosed = true
+	delete(a.current, key)
+	delete(a.dirty, key)
+	return ohlc
+}
+
+// updateRenko applies a tick to the Renko series identified by key and returns the bricks it
+// completed. A brick is formed each time price moves a brick size beyond the top or bottom of the
+// previous one, so a reversal takes twice the size; the first brick carries the volume traded
+// since the previous brick. Bricks formed by the same tick open a microsecond apart, so bricks
+// are ordered and told apart by open time
+func (a *aggregator) updateRenko(key candleKey, spec BarSpec, tick Tick) []*OHLC {
+	pending, exists := a.current[key]
+	if !exists {
+		pending = a.newBar(key, tick)
+		if last, ok := a.last[key]; ok {
+			pending.OpenTime = brickOpenTime(last.OpenTime, tick.Timestamp)
+			pending.CloseTime = pending.OpenTime
+		}
+		a.current[key] = pending
+	} else {
+		addTick(pending, tick, a.precisions.For(key.symbol))
+		pending.CloseTime = laterOf(pending.OpenTime, tick.Timestamp)
+	}
+	a.dirty[key] = true
+
+	// The first brick is measured from the first price seen
+	top, bottom := pending.Open, pending.Open
+	if last, ok := a.last[key]; ok {
+		top, bottom = decimal.Max(last.Open, last.Close), decimal.Min(last.Open, last.Close)
+	}
+
+	var bricks []*OHLC
+	for {
+		brick := &OHLC{}
+		switch {
+		case tick.Price.GreaterThanOrEqual(top.Add(spec.Threshold)):
+			brick.Open, brick.Close = top, top.Add(spec.Threshold)
+		case tick.Price.LessThanOrEqual(bottom.Sub(spec.Threshold)):
+			brick.Open, brick.Close = bottom, bottom.Sub(spec.Threshold)
+		default:
+			if len(bricks) > 0 {
+				// Start the next brick from the last one, without any volume yet
+				last := bricks[len(bricks)-1]
+				openTime := brickOpenTime(last.OpenTime, tick.Timestamp)
+				a.current[key] = &OHLC{
+					Symbol:       key.symbol,
+					Interval:     key.interval,
+					BarType:      BarTypeRenko,
+					Open:         last.Close,
+					High:         decimal.Max(last.Close, tick.Price),
+					Low:          decimal.Min(last.Close, tick.Price),
+					Close:        tick.Price,
+					FirstTradeID: tick.LastTradeID + 1,
+					LastTradeID:  tick.LastTradeID,
+					OpenTime:     openTime,
+					CloseTime:    openTime,
+				}
+				a.dirty[key] = true
+			}
+			return bricks
+		}
+
+		// Only the first brick formed by a tick carries the pending volume and trades
+		open, close := brick.Open, brick.Close
+		if len(bricks) == 0 {
+			*brick = *pending
+		} else {
+			openTime := brickOpenTime(bricks[len(bricks)-1].OpenTime, tick.Timestamp)
+			*brick = OHLC{Symbol: key.symbol, Interval: key.interval, BarType: BarTypeRenko, FirstTradeID: tick.LastTradeID, LastTradeID: tick.LastTradeID, OpenTime: openTime}
+		}
+		brick.Open, brick.Close = open, close
+		brick.High, brick.Low = decimal.Max(open, close), decimal.Min(open, close)
+		brick.CloseTime = laterOf(brick.OpenTime, tick.Timestamp)
+		brick.IsClosed = true
+		log.Printf("Closing %s brick for symbol=%s at price=%s", key.interval, key.symbol, close)
+
+		bricks = append(bricks, brick)
+		a.last[key] = brick
+		top, bottom = brick.High, brick.Low
+		delete(a.current, key)
+		delete(a.dirty, key)
+	}
+}
+
+// brickOpenTime returns the open time of a brick formed at t after a brick opening at prev: t, or a
+// microsecond, the precision candles are stored with, after prev if t is not later
+func brickOpenTime(prev, t time.Time) time.Time {
+	if t.After(prev) {
+		return t
+	}
+	return prev.Add(time.Microsecond)
+}
+
+// laterOf returns the later of two times
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// containsBar checks whether the in-progress bar identified by key already accounts for tick
+func (a *aggregator) containsBar(key candleKey, tick Tick) bool {
+	ohlc, ok := a.current[key]
+	return ok && tick.LastTradeID <= ohlc.LastTradeID
+}
+
+// contains checks whether the candle identified by key already accounts for tick, which is the
+// case for ticks older than the in-progress candle or up to its last trade ID
+func (a *aggregator) contains(key candleKey, tick Tick) bool {
+	startTime := a.calendar.Truncate(key.interval, tick.Timestamp)
+	if a.isLate(key, startTime) {
+		return true
+	}
+	ohlc, ok := a.current[key]
+	return ok && ohlc.OpenTime.Equal(startTime) && tick.LastTradeID <= ohlc.LastTradeID
+}
+
+// update applies a tick to the candle identified by key and returns the candles it completed
+// or revised
+func (a *aggregator) update(key candleKey, tick Tick) []*OHLC {
+	startTime := a.calendar.Truncate(key.interval, tick.Timestamp)
+
+	// Ticks belonging to an already completed candle correct it
+	if a.isLate(key, startTime) {
+		if revised := a.revise(key, startTime, tick); revised != nil {
+			return []*OHLC{revised}
+		}
+		return nil
+	}
+
+	// Get or create current OHLC for the symbol and interval
+	ohlc, exists := a.current[key]
+	if !exists || a.shouldStartNewCandle(startTime, ohlc) {
 		// If we have an existing OHLC, it's complete
-		var completed *OHLC
+		var completed []*OHLC
 		if exists {
-			completed = ohlc
+			completed = append(completed, a.complete(key, ohlc))
 		}
 
-		// Start a new candle
-		startTime := tick.Timestamp.Truncate(a.interval)
-		log.Printf("Starting new candle for symbol=%s at time=%s", tick.Symbol, startTime.Format(time.RFC3339))
-		a.current[tick.Symbol] = &OHLC{
-			Symbol:    tick.Symbol,
-			Open:      tick.Price,
-			High:      tick.Price,
-			Low:       tick.Price,
-			Close:     tick.Price,
-			Volume:    tick.Quantity,
-			OpenTime:  startTime,
-			CloseTime: startTime.Add(a.interval),
-		}
+		// Start a new candle, filling any interval skipped since the last one
+		completed = append(completed, a.fill(key, startTime)...)
+		log.Printf("Starting new %s candle for symbol=%s at time=%s", key.interval, tick.Symbol, startTime.Format(time.RFC3339))
+		a.current[key] = a.newCandle(key, startTime, tick)
+		a.dirty[key] = true
 
-		return completed, nil
+		return completed
 	}
 
 	// Update current OHLC
-	ohlc.High = max(ohlc.High, tick.Price)
-	ohlc.Low = min(ohlc.Low, tick.Price)
-	ohlc.Close = tick.Price
-	ohlc.Volume += tick.Quantity
+	addTick(ohlc, tick, a.precisions.For(key.symbol))
+	a.dirty[key] = true
+
+	return nil
+}
+
+// isLate checks whether a candle starting at startTime has already been completed
+func (a *aggregator) isLate(key candleKey, startTime time.Time) bool {
+	if current, ok := a.current[key]; ok && startTime.Before(current.OpenTime) {
+		return true
+	}
+	if last, ok := a.last[key]; ok && startTime.Before(last.CloseTime) {
+		return true
+	}
+	return false
+}
+
+// revise applies a late tick to the completed candle starting at startTime and returns the
+// corrected candle, a new candle at revision 0 if none was emitted for the interval, or nil if
+// the tick is older than the allowed lateness
+func (a *aggregator) revise(key candleKey, startTime time.Time, tick Tick) *OHLC {
+	closeTime := a.calendar.Next(key.interval, startTime)
+	if a.allowedLateness <= 0 || a.watermarks[key.symbol].Sub(closeTime) > a.allowedLateness {
+		a.stats.DroppedTicks++
+		log.Printf("Dropping late tick for %s candle: symbol=%s, timestamp=%s, watermark=%s",
+			key.interval, tick.Symbol, tick.Timestamp.Format(time.RFC3339), a.watermarks[key.symbol].Format(time.RFC3339))
+		return nil
+	}
+
+	history := a.history[key]
+	i := sort.Search(len(history), func(i int) bool {
+		return !history[i].OpenTime.Before(startTime)
+	})
+
+	var revised *OHLC
+	switch {
+	case i < len(history) && history[i].OpenTime.Equal(startTime) && !history[i].Synthetic:
+		// Copy the candle, the previous revision may still be referenced by consumers
+		copy := *history[i]
+		revised = &copy
+		addTick(revised, tick, a.precisions.For(key.symbol))
+		history[i] = revised
+	case i < len(history) && history[i].OpenTime.Equal(startTime):
+		// The first trade of a gap-filled candle replaces it
+		revised = a.newCandle(key, startTime, tick)
+		revised.Revision = history[i].Revision
+		history[i] = revised
+	default:
+		// No candle was emitted for this interval yet, so this is its first publication rather
+		// than a revision
+		revised = a.newCandle(key, startTime, tick)
+		revised.IsClosed = true
+		a.history[key] = append(history[:i], append([]*OHLC{revised}, history[i:]...)...)
+		log.Printf("Emitting late %s candle for symbol=%s at time=%s", key.interval, key.symbol, startTime.Format(time.RFC3339))
+		return revised
+	}
+
+	revised.Revision++
+	revised.IsClosed = true
+	if last, ok := a.last[key]; ok && last.OpenTime.Equal(startTime) {
+		a.last[key] = revised
+	}
+	a.stats.Revisions++
+	log.Printf("Revising %s candle for symbol=%s at time=%s (revision %d)", key.interval, key.symbol, startTime.Format(time.RFC3339), revised.Revision)
+
+	return revised
+}
+
+// complete marks the candle identified by key as closed and removes it from the in-progress candles
+func (a *aggregator) complete(key candleKey, ohlc *OHLC) *OHLC {
+	ohlc.IsClosed = true
+	a.remember(key, ohlc)
+	delete(a.current, key)
+	delete(a.dirty, key)
+	return ohlc
+}
+
+// remember records a completed candle, keeping it around while it may still be revised
+func (a *aggregator) remember(key candleKey, ohlc *OHLC) {
+	a.last[key] = ohlc
+	if a.allowedLateness <= 0 {
+		return
+	}
+
+	// Forget candles that can no longer receive late ticks
+	history := append(a.history[key], ohlc)
+	watermark := a.watermarks[key.symbol]
+	expired := 0
+	for expired < len(history) && watermark.Sub(history[expired].CloseTime) > a.allowedLateness {
+		expired++
+	}
+	a.history[key] = history[expired:]
+}
+
+// Flush completes and returns every in-progress OHLC whose close time is at or before now
+func (a *aggregator) Flush(now time.Time) []*OHLC {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var completed []*OHLC
+	for key, ohlc := range a.current {
+		// Bars only close on their threshold
+		if key.interval.IsBar() || ohlc.CloseTime.After(now) {
+			continue
+		}
+		log.Printf("Closing %s candle for symbol=%s at time=%s", key.interval, key.symbol, ohlc.CloseTime.Format(time.RFC3339))
+		completed = append(completed, a.complete(key, ohlc))
+	}
+
+	// Fill intervals that ended without any trade
+	for key := range a.last {
+		if _, ok := a.current[key]; !ok && !key.interval.IsBar() {
+			completed = append(completed, a.fill(key, now)...)
+		}
+	}
+
+	// Keep the output stable regardless of map iteration order
+	sortOHLCs(completed)
 
-	return nil, nil
+	return completed
 }
 
-// Current returns the current in-progress OHLC
-func (a *aggregator) Current() *OHLC {
+// fill returns synthetic candles for every interval between the last completed candle and until,
+// if gap filling is enabled
+func (a *aggregator) fill(key candleKey, until time.Time) []*OHLC {
+	last, ok := a.last[key]
+	if !a.gapFill || !ok {
+		return nil
+	}
+
+	var filled []*OHLC
+	for !a.calendar.Next(key.interval, last.CloseTime).After(until) {
+		last = flatCandle(last, key.interval, a.calendar)
+		filled = append(filled, last)
+		a.remember(key, last)
+	}
+	if len(filled) > 0 {
+		log.Printf("Filled %d empty %s candles for symbol=%s", len(filled), key.interval, key.symbol)
+	}
+
+	return filled
+}
+
+// Updates returns copies of the in-progress OHLCs that changed since the previous call,
+// conflating any number of ticks into a single update per candle
+func (a *aggregator) Updates() []*OHLC {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	updates := make([]*OHLC, 0, len(a.dirty))
+	for key := range a.dirty {
+		copy := *a.current[key]
+		updates = append(updates, &copy)
+	}
+	clear(a.dirty)
+	sortOHLCs(updates)
+
+	return updates
+}
+
+// Current returns a copy of the in-progress OHLC for a symbol and interval
+func (a *aggregator) Current(symbol Symbol, interval Interval) *OHLC {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	// Return a copy to prevent external modifications
-	if len(a.current) == 0 {
+	ohlc, ok := a.current[candleKey{symbol: symbol, interval: interval}]
+	if !ok {
 		return nil
 	}
 
-	// Return the first available OHLC (since we typically track one symbol)
+	// Return a copy to prevent external modifications
+	copy := *ohlc
+	return &copy
+}
+
+// Snapshot returns copies of all in-progress OHLCs
+func (a *aggregator) Snapshot() []*OHLC {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := make([]*OHLC, 0, len(a.current))
 	for _, ohlc := range a.current {
 		copy := *ohlc
-		return &copy
+		snapshot = append(snapshot, &copy)
 	}
+	sortOHLCs(snapshot)
 
-	return nil
+	return snapshot
+}
+
+// Stats returns counters about late tick handling
+func (a *aggregator) Stats() AggregatorStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.stats
 }
 
-// shouldStartNewCandle checks if it's time to start a new candlestick
-func (a *aggregator) shouldStartNewCandle(timestamp time.Time, current *OHLC) bool {
+// shouldStartNewCandle checks if a tick in the candle starting at startTime begins a new candlestick
+func (a *aggregator) shouldStartNewCandle(startTime time.Time, current *OHLC) bool {
 	if current == nil {
 		return true
 	}
-	return timestamp.After(current.CloseTime)
+	return !startTime.Before(current.CloseTime)
 }
 
 // Helper functions
-func max(a, b float64) float64 {
-	if a > b {
-		return a
+
+// newCandle starts a candle for key at startTime from its first tick
+func (a *aggregator) newCandle(key candleKey, startTime time.Time, tick Tick) *OHLC {
+	ohlc := &OHLC{
+		Symbol:       tick.Symbol,
+		Interval:     key.interval,
+		Open:         tick.Price,
+		High:         tick.Price,
+		Low:          tick.Price,
+		FirstTradeID: tick.FirstTradeID,
+		OpenTime:     startTime,
+		CloseTime:    a.calendar.Next(key.interval, startTime),
+		BarType:      BarTypeTime,
 	}
-	return b
+	addTick(ohlc, tick, a.precisions.For(key.symbol))
+	return ohlc
 }
 
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+// newBar starts a bar for key from its first tick, spanning the timestamps of its ticks
+func (a *aggregator) newBar(key candleKey, tick Tick) *OHLC {
+	ohlc := &OHLC{
+		Symbol:       tick.Symbol,
+		Interval:     key.interval,
+		Open:         tick.Price,
+		High:         tick.Price,
+		Low:          tick.Price,
+		FirstTradeID: tick.FirstTradeID,
+		OpenTime:     tick.Timestamp,
+		CloseTime:    tick.Timestamp,
+		BarType:      key.interval.BarType(),
 	}
-	return b
+	addTick(ohlc, tick, a.precisions.For(key.symbol))
+	return ohlc
+}
+
+// addTick updates a candle with a tick, using trade IDs to keep open and close correct
+// when ticks arrive out of order
+func addTick(ohlc *OHLC, tick Tick, precision Precision) {
+	quoteQuantity := tick.Price.Mul(tick.Quantity)
+
+	if tick.FirstTradeID < ohlc.FirstTradeID {
+		ohlc.Open = tick.Price
+		ohlc.FirstTradeID = tick.FirstTradeID
+	}
+	if tick.LastTradeID >= ohlc.LastTradeID {
+		ohlc.Close = tick.Price
+		ohlc.LastTradeID = tick.LastTradeID
+	}
+	ohlc.High = decimal.Max(ohlc.High, tick.Price)
+	ohlc.Low = decimal.Min(ohlc.Low, tick.Price)
+	ohlc.Volume = ohlc.Volume.Add(tick.Quantity)
+	ohlc.QuoteVolume = ohlc.QuoteVolume.Add(quoteQuantity)
+	if !tick.IsBuyerMaker {
+		ohlc.TakerBuyVolume = ohlc.TakerBuyVolume.Add(tick.Quantity)
+		ohlc.TakerBuyQuoteVolume = ohlc.TakerBuyQuoteVolume.Add(quoteQuantity)
+	}
+	if ohlc.Volume.IsPositive() {
+		ohlc.VWAP = ohlc.QuoteVolume.DivRound(ohlc.Volume, precision.Price)
+	}
+	ohlc.Trades += tick.TradeCount()
+}
+
+// sortOHLCs orders candles by symbol, interval length and open time
+func sortOHLCs(ohlcs []*OHLC) {
+	sort.Slice(ohlcs, func(i, j int) bool {
+		if ohlcs[i].Symbol != ohlcs[j].Symbol {
+			return ohlcs[i].Symbol < ohlcs[j].Symbol
+		}
+		if ohlcs[i].Interval != ohlcs[j].Interval {
+			return ohlcs[i].Interval.Duration() < ohlcs[j].Interval.Duration()
+		}
+		return ohlcs[i].OpenTime.Before(ohlcs[j].OpenTime)
+	})
 }
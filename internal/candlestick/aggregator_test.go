@@ -3,12 +3,17 @@ package candlestick
 import (
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// dec parses a decimal literal, panicking on malformed input
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
 func TestNewAggregator(t *testing.T) {
-	interval := time.Minute
-	storage := NewMockStorage()
-	agg := NewAggregator(interval, storage)
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}})
 	if agg == nil {
 		t.Fatal("Expected non-nil aggregator")
 	}
@@ -16,22 +21,7 @@ func TestNewAggregator(t *testing.T) {
 
 func TestProcess(t *testing.T) {
 	interval := time.Minute
-	storage := NewMockStorage()
-	agg := NewAggregator(interval, storage)
-
-	// Helper function to verify tick storage
-	// verifyStoredTick := func(t *testing.T, expected Tick) {
-	// 	stored := storage.(*mockStorage).GetStoredTicks()
-	// 	if len(stored) == 0 {
-	// 		t.Fatal("Expected tick to be stored")
-	// 	}
-	// 	lastStored := stored[len(stored)-1]
-	// 	if lastStored.Symbol != expected.Symbol ||
-	// 		lastStored.Price != expected.Price ||
-	// 		lastStored.Quantity != expected.Quantity {
-	// 		t.Errorf("Stored tick does not match expected: got %+v, want %+v", lastStored, expected)
-	// 	}
-	// }
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}})
 
 	tests := []struct {
 		name      string
@@ -42,8 +32,8 @@ func TestProcess(t *testing.T) {
 			name: "First tick",
 			tick: Tick{
 				Symbol:    BTCUSDT,
-				Price:     50000.0,
-				Quantity:  1.0,
+				Price:     dec("50000.0"),
+				Quantity:  dec("1.0"),
 				Timestamp: time.Now(),
 			},
 			expectNil: true,
@@ -52,8 +42,8 @@ func TestProcess(t *testing.T) {
 			name: "Higher price",
 			tick: Tick{
 				Symbol:    BTCUSDT,
-				Price:     51000.0,
-				Quantity:  0.5,
+				Price:     dec("51000.0"),
+				Quantity:  dec("0.5"),
 				Timestamp: time.Now(),
 			},
 			expectNil: true,
@@ -62,8 +52,8 @@ func TestProcess(t *testing.T) {
 			name: "Lower price",
 			tick: Tick{
 				Symbol:    BTCUSDT,
-				Price:     49000.0,
-				Quantity:  1.5,
+				Price:     dec("49000.0"),
+				Quantity:  dec("1.5"),
 				Timestamp: time.Now().Add(interval),
 			},
 			expectNil: false,
@@ -72,7 +62,7 @@ func TestProcess(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ohlc, err := agg.Process(tt.tick)
+			completed, err := agg.Process(tt.tick)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -80,18 +70,19 @@ func TestProcess(t *testing.T) {
 			// Verify tick was stored correctly
 			// verifyStoredTick(t, tt.tick)
 
-			if tt.expectNil && ohlc != nil {
-				t.Error("Expected nil OHLC, got non-nil")
+			if tt.expectNil && len(completed) != 0 {
+				t.Error("Expected no completed OHLC, got some")
 			}
 
 			if !tt.expectNil {
-				if ohlc == nil {
-					t.Fatal("Expected non-nil OHLC")
+				if len(completed) != 1 {
+					t.Fatalf("Expected 1 completed OHLC, got %d", len(completed))
 				}
+				ohlc := completed[0]
 				if ohlc.Symbol != tt.tick.Symbol {
 					t.Errorf("Expected symbol %s, got %s", tt.tick.Symbol, ohlc.Symbol)
 				}
-				if ohlc.Volume <= 0 {
+				if !ohlc.Volume.IsPositive() {
 					t.Error("Expected positive volume")
 				}
 			}
@@ -100,20 +91,18 @@ func TestProcess(t *testing.T) {
 }
 
 func TestCurrent(t *testing.T) {
-	interval := time.Minute
-	storage := NewMockStorage()
-	agg := NewAggregator(interval, storage)
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}})
 
 	// Test empty state
-	if current := agg.Current(); current != nil {
+	if current := agg.Current(BTCUSDT, Interval1m); current != nil {
 		t.Error("Expected nil current OHLC when no ticks processed")
 	}
 
 	// Process a tick
 	tick := Tick{
 		Symbol:    BTCUSDT,
-		Price:     50000.0,
-		Quantity:  1.0,
+		Price:     dec("50000.0"),
+		Quantity:  dec("1.0"),
 		Timestamp: time.Now(),
 	}
 
@@ -123,7 +112,7 @@ func TestCurrent(t *testing.T) {
 	}
 
 	// Get current candle
-	current := agg.Current()
+	current := agg.Current(BTCUSDT, Interval1m)
 	if current == nil {
 		t.Fatal("Expected non-nil current OHLC")
 	}
@@ -132,11 +121,334 @@ func TestCurrent(t *testing.T) {
 		t.Errorf("Expected symbol %s, got %s", tick.Symbol, current.Symbol)
 	}
 
-	if current.Open != tick.Price {
-		t.Errorf("Expected open price %f, got %f", tick.Price, current.Open)
+	if !current.Open.Equal(tick.Price) {
+		t.Errorf("Expected open price %s, got %s", tick.Price, current.Open)
+	}
+
+	if !current.Volume.Equal(tick.Quantity) {
+		t.Errorf("Expected volume %s, got %s", tick.Quantity, current.Volume)
+	}
+}
+
+func TestCurrentPerSymbol(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m, Interval1h}})
+
+	now := time.Now()
+	ticks := []Tick{
+		{Symbol: BTCUSDT, Price: dec("50000.0"), Quantity: dec("1.0"), Timestamp: now},
+		{Symbol: ETHUSDT, Price: dec("3000.0"), Quantity: dec("2.0"), Timestamp: now},
+		{Symbol: PEPEUSDT, Price: dec("0.00001"), Quantity: dec("1e6"), Timestamp: now},
+	}
+	for _, tick := range ticks {
+		if _, err := agg.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	for _, tick := range ticks {
+		current := agg.Current(tick.Symbol, Interval1h)
+		if current == nil {
+			t.Fatalf("Expected current OHLC for %s", tick.Symbol)
+		}
+		if current.Symbol != tick.Symbol || current.Interval != Interval1h || !current.Open.Equal(tick.Price) {
+			t.Errorf("Unexpected current OHLC for %s: %+v", tick.Symbol, current)
+		}
+	}
+
+	// Symbols that were never traded have no current candle
+	if current := agg.Current(Symbol("DOGEUSDT"), Interval1m); current != nil {
+		t.Errorf("Expected nil current OHLC, got %+v", current)
+	}
+
+	// Snapshot holds copies of every in-progress candle
+	snapshot := agg.Snapshot()
+	if len(snapshot) != len(ticks)*2 {
+		t.Fatalf("Expected %d candles in snapshot, got %d", len(ticks)*2, len(snapshot))
+	}
+	snapshot[0].Close = dec("-1")
+	if current := agg.Current(snapshot[0].Symbol, snapshot[0].Interval); current.Close.Equal(dec("-1")) {
+		t.Error("Expected snapshot to be a copy")
+	}
+}
+
+func TestProcessMultipleIntervals(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m, Interval5m}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ticks := []Tick{
+		{Symbol: BTCUSDT, Price: dec("50000.0"), Quantity: dec("1.0"), Timestamp: start.Add(10 * time.Second)},
+		{Symbol: BTCUSDT, Price: dec("50500.0"), Quantity: dec("2.0"), Timestamp: start.Add(70 * time.Second)},
+		{Symbol: BTCUSDT, Price: dec("49500.0"), Quantity: dec("3.0"), Timestamp: start.Add(5*time.Minute + time.Second)},
+	}
+
+	var completed []*OHLC
+	for _, tick := range ticks {
+		ohlcs, err := agg.Process(tick)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		completed = append(completed, ohlcs...)
+	}
+
+	// Two 1m candles and one 5m candle should have completed
+	counts := make(map[Interval]int)
+	for _, ohlc := range completed {
+		counts[ohlc.Interval]++
 	}
+	if counts[Interval1m] != 2 || counts[Interval5m] != 1 {
+		t.Fatalf("Unexpected completed candles per interval: %v", counts)
+	}
+
+	for _, ohlc := range completed {
+		if ohlc.Interval != Interval5m {
+			continue
+		}
+		if !ohlc.OpenTime.Equal(start) || !ohlc.CloseTime.Equal(start.Add(5*time.Minute)) {
+			t.Errorf("Unexpected 5m candle period: %s - %s", ohlc.OpenTime, ohlc.CloseTime)
+		}
+		if !ohlc.Open.Equal(dec("50000")) || !ohlc.High.Equal(dec("50500")) || !ohlc.Close.Equal(dec("50500")) {
+			t.Errorf("Unexpected 5m candle prices: %+v", ohlc)
+		}
+		if !ohlc.Volume.Equal(dec("3")) {
+			t.Errorf("Expected 5m volume 3, got %s", ohlc.Volume)
+		}
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, price := range []string{"100", "101", "99"} {
+		if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec(price), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Several ticks are conflated into a single in-progress update
+	updates := agg.Updates()
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 update, got %d", len(updates))
+	}
+	if updates[0].IsClosed || !updates[0].Close.Equal(dec("99")) || !updates[0].Volume.Equal(dec("3")) {
+		t.Errorf("Unexpected update: %+v", updates[0])
+	}
+
+	// Nothing changed since the last call
+	if updates := agg.Updates(); len(updates) != 0 {
+		t.Fatalf("Expected no updates, got %d", len(updates))
+	}
+
+	// A closed candle is never reported as an in-progress update
+	if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("102"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	completed := agg.Flush(start.Add(time.Minute))
+	if len(completed) != 1 || !completed[0].IsClosed {
+		t.Fatalf("Expected a closed candle, got %+v", completed)
+	}
+	if updates := agg.Updates(); len(updates) != 0 {
+		t.Fatalf("Expected no updates after close, got %d", len(updates))
+	}
+}
+
+func TestProcessTradeStatistics(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}, Precisions: DefaultPrecisions})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ticks := []Tick{
+		// Aggressive buy covering trades 10-12
+		{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("2"), Timestamp: start, TradeID: 1, FirstTradeID: 10, LastTradeID: 12},
+		// Aggressive sell covering trade 13
+		{Symbol: BTCUSDT, Price: dec("110"), Quantity: dec("1"), Timestamp: start.Add(time.Second), TradeID: 2, FirstTradeID: 13, LastTradeID: 13, IsBuyerMaker: true},
+	}
+	for _, tick := range ticks {
+		if _, err := agg.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	ohlc := agg.Current(BTCUSDT, Interval1m)
+	if ohlc == nil {
+		t.Fatal("Expected non-nil current OHLC")
+	}
+	if !ohlc.QuoteVolume.Equal(dec("310")) {
+		t.Errorf("Expected quote volume 310, got %s", ohlc.QuoteVolume)
+	}
+	if !ohlc.TakerBuyVolume.Equal(dec("2")) || !ohlc.TakerBuyQuoteVolume.Equal(dec("200")) {
+		t.Errorf("Expected taker buy volume 2/200, got %s/%s", ohlc.TakerBuyVolume, ohlc.TakerBuyQuoteVolume)
+	}
+	// 310 / 3 rounded to the 2 decimal places BTCUSDT is quoted with
+	if vwap := dec("103.33"); !ohlc.VWAP.Equal(vwap) {
+		t.Errorf("Expected VWAP %s, got %s", vwap, ohlc.VWAP)
+	}
+	if ohlc.Trades != 4 || ohlc.FirstTradeID != 10 || ohlc.LastTradeID != 13 {
+		t.Errorf("Expected 4 trades from 10 to 13, got %d from %d to %d", ohlc.Trades, ohlc.FirstTradeID, ohlc.LastTradeID)
+	}
+}
 
-	if current.Volume != tick.Quantity {
-		t.Errorf("Expected volume %f, got %f", tick.Quantity, current.Volume)
+func TestProcessLateTicks(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}, AllowedLateness: 2 * time.Minute})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ticks := []Tick{
+		{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second), FirstTradeID: 1, LastTradeID: 1},
+		{Symbol: BTCUSDT, Price: dec("101"), Quantity: dec("1"), Timestamp: start.Add(70 * time.Second), FirstTradeID: 3, LastTradeID: 3},
+	}
+	for _, tick := range ticks {
+		if _, err := agg.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// A late tick is routed to the closed candle it belongs to instead of the current one
+	completed, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("120"), Quantity: dec("2"), Timestamp: start.Add(50 * time.Second), FirstTradeID: 2, LastTradeID: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 1 {
+		t.Fatalf("Expected one revised candle, got %d", len(completed))
+	}
+	revised := completed[0]
+	if revised.Revision != 1 || !revised.IsClosed || !revised.OpenTime.Equal(start) {
+		t.Errorf("Unexpected revision: %+v", revised)
+	}
+	if !revised.High.Equal(dec("120")) || !revised.Close.Equal(dec("120")) || !revised.Volume.Equal(dec("3")) {
+		t.Errorf("Expected the late tick in the revised candle, got %+v", revised)
+	}
+	if current := agg.Current(BTCUSDT, Interval1m); !current.Volume.Equal(dec("1")) || !current.High.Equal(dec("101")) {
+		t.Errorf("Expected the current candle to be untouched, got %+v", current)
+	}
+
+	// Ticks older than the allowed lateness are dropped and counted
+	if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("102"), Quantity: dec("1"), Timestamp: start.Add(5 * time.Minute)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	completed, err = agg.Process(Tick{Symbol: BTCUSDT, Price: dec("90"), Quantity: dec("1"), Timestamp: start.Add(30 * time.Second)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 0 {
+		t.Fatalf("Expected the late tick to be dropped, got %+v", completed)
+	}
+	if stats := agg.Stats(); stats.Revisions != 1 || stats.DroppedTicks != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestProcessLateTickOfEmptyInterval(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}, AllowedLateness: 2 * time.Minute})
+
+	// No candle is emitted for 12:01, which had no trades
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{10 * time.Second, 130 * time.Second} {
+		if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(offset)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// A late tick for 12:01 publishes its candle for the first time, at revision 0
+	completed, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("110"), Quantity: dec("2"), Timestamp: start.Add(90 * time.Second)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 1 {
+		t.Fatalf("Expected one late candle, got %d", len(completed))
+	}
+	late := completed[0]
+	if late.Revision != 0 || !late.IsClosed || !late.OpenTime.Equal(start.Add(time.Minute)) || !late.Volume.Equal(dec("2")) {
+		t.Errorf("Unexpected late candle: %+v", late)
+	}
+
+	// Further late ticks revise it
+	completed, err = agg.Process(Tick{Symbol: BTCUSDT, Price: dec("111"), Quantity: dec("1"), Timestamp: start.Add(100 * time.Second)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 1 || completed[0].Revision != 1 || !completed[0].Volume.Equal(dec("3")) {
+		t.Errorf("Expected revision 1 of the late candle, got %+v", completed)
+	}
+	if stats := agg.Stats(); stats.Revisions != 1 {
+		t.Errorf("Expected 1 revision, got %+v", stats)
+	}
+}
+
+func TestProcessExactDecimals(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1d}, Precisions: DefaultPrecisions})
+
+	// Summing 0.1 a thousand times drifts with float64, decimals stay exact
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 1000; i++ {
+		tick := Tick{Symbol: PEPEUSDT, Price: dec("0.00001234"), Quantity: dec("0.1"), Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if _, err := agg.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	ohlc := agg.Current(PEPEUSDT, Interval1d)
+	if !ohlc.Volume.Equal(dec("100")) {
+		t.Errorf("Expected volume 100, got %s", ohlc.Volume)
+	}
+	if !ohlc.QuoteVolume.Equal(dec("0.001234")) {
+		t.Errorf("Expected quote volume 0.001234, got %s", ohlc.QuoteVolume)
+	}
+	if !ohlc.VWAP.Equal(dec("0.00001234")) {
+		t.Errorf("Expected VWAP 0.00001234, got %s", ohlc.VWAP)
+	}
+}
+
+func TestRestoreAndReplay(t *testing.T) {
+	intervals := []Interval{Interval1m, Interval5m}
+	storage := NewMockStorage()
+	agg := NewAggregator(AggregatorConfig{Intervals: intervals})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ticks := make([]Tick, 0, 6)
+	for i, price := range []string{"100", "105", "95", "101", "103", "99"} {
+		id := int64(i + 1)
+		ticks = append(ticks, Tick{Symbol: BTCUSDT, Price: dec(price), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * 20 * time.Second), TradeID: id, FirstTradeID: id, LastTradeID: id})
+	}
+	for _, tick := range ticks {
+		if _, err := agg.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := storage.StoreTicks([]*Tick{&tick}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expected := agg.Snapshot()
+
+	// The snapshot is taken after the fourth tick, the remaining ticks only reached the ticks table
+	restarted := NewAggregator(AggregatorConfig{Intervals: intervals})
+	for _, tick := range ticks[:4] {
+		if _, err := restarted.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	snapshot := restarted.Snapshot()
+
+	restored := NewAggregator(AggregatorConfig{Intervals: intervals})
+	restored.Restore(snapshot)
+	stored, err := storage.GetTicks(BTCUSDT, start, start.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stored) != len(ticks) {
+		t.Fatalf("Expected %d stored ticks, got %d", len(ticks), len(stored))
+	}
+	for _, tick := range stored {
+		restored.Replay(*tick)
+	}
+
+	actual := restored.Snapshot()
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d candles, got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		e, a := expected[i], actual[i]
+		if !a.OpenTime.Equal(e.OpenTime) || !a.Open.Equal(e.Open) || !a.High.Equal(e.High) || !a.Low.Equal(e.Low) ||
+			!a.Close.Equal(e.Close) || !a.Volume.Equal(e.Volume) || a.Trades != e.Trades {
+			t.Errorf("Expected restored %s candle %+v, got %+v", e.Interval, e, a)
+		}
 	}
 }
@@ -0,0 +1,132 @@
+package candlestick
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// BarType identifies the rule closing a bar
+type BarType string
+
+const (
+	BarTypeTime   BarType = "time"   // closes on interval boundaries
+	BarTypeTick   BarType = "tick"   // closes after a number of exchange trades
+	BarTypeVolume BarType = "volume" // closes after a base asset volume
+	BarTypeDollar BarType = "dollar" // closes after a quote asset notional
+	BarTypeRange  BarType = "range"  // closes once its high-low range reaches a size
+	BarTypeRenko  BarType = "renko"  // fixed-size bricks formed when price moves past the previous brick
+
+	BarTypeHeikinAshi BarType = "heikin_ashi" // Heikin-Ashi transform of closed time candles
+)
+
+// IsBar reports whether candles of the type close on a threshold rather than on a time boundary,
+// so that several of them may open at the same time
+func (b BarType) IsBar() bool {
+	switch b {
+	case BarTypeTick, BarTypeVolume, BarTypeDollar, BarTypeRange, BarTypeRenko:
+		return true
+	}
+	return false
+}
+
+// BarSpec describes an information-driven bar, closed once its threshold is reached by the
+// tick that crosses it, or a Renko brick size. Bars are identified by "type:threshold", e.g.
+// "volume:10", which is used as their Interval
+type BarSpec struct {
+	Type      BarType
+	Threshold decimal.Decimal
+}
+
+// ParseBarSpec parses a bar identifier such as "tick:1000", "volume:10", "dollar:1e6",
+// "range:50" or "renko:25"
+func ParseBarSpec(s string) (BarSpec, error) {
+	barType, threshold, ok := strings.Cut(s, ":")
+	if !ok {
+		return BarSpec{}, fmt.Errorf("invalid bar %q, expected type:threshold", s)
+	}
+
+	spec := BarSpec{Type: BarType(barType)}
+	switch spec.Type {
+	case BarTypeTick, BarTypeVolume, BarTypeDollar, BarTypeRange, BarTypeRenko:
+	default:
+		return BarSpec{}, fmt.Errorf("unsupported bar type %q", barType)
+	}
+
+	var err error
+	if spec.Threshold, err = decimal.NewFromString(threshold); err != nil {
+		return BarSpec{}, fmt.Errorf("invalid bar threshold %q: %v", threshold, err)
+	}
+	if !spec.Threshold.IsPositive() {
+		return BarSpec{}, fmt.Errorf("bar threshold must be positive, got %s", threshold)
+	}
+	if spec.Type == BarTypeTick && !spec.Threshold.IsInteger() {
+		return BarSpec{}, fmt.Errorf("tick bar threshold must be an integer, got %s", threshold)
+	}
+
+	return spec, nil
+}
+
+// String returns the canonical bar identifier
+func (b BarSpec) String() string {
+	return string(b.Type) + ":" + b.Threshold.String()
+}
+
+// Interval returns the identifier of the bar's candles
+func (b BarSpec) Interval() Interval {
+	return Interval(b.String())
+}
+
+// full checks whether a bar reached the threshold and must be closed
+func (b BarSpec) full(ohlc *OHLC) bool {
+	switch b.Type {
+	case BarTypeTick:
+		return decimal.NewFromInt(ohlc.Trades).GreaterThanOrEqual(b.Threshold)
+	case BarTypeVolume:
+		return ohlc.Volume.GreaterThanOrEqual(b.Threshold)
+	case BarTypeDollar:
+		return ohlc.QuoteVolume.GreaterThanOrEqual(b.Threshold)
+	case BarTypeRange:
+		return ohlc.High.Sub(ohlc.Low).GreaterThanOrEqual(b.Threshold)
+	}
+	return false
+}
+
+// HeikinAshiInterval returns the identifier of the Heikin-Ashi series derived from interval
+func HeikinAshiInterval(interval Interval) Interval {
+	return Interval(string(BarTypeHeikinAshi) + ":" + string(interval))
+}
+
+// IsBar checks whether the interval identifies bars or a derived series rather than time candles
+func (i Interval) IsBar() bool {
+	return strings.Contains(string(i), ":")
+}
+
+// BarType returns the type of the interval's candles
+func (i Interval) BarType() BarType {
+	if barType, _, ok := strings.Cut(string(i), ":"); ok {
+		return BarType(barType)
+	}
+	return BarTypeTime
+}
+
+// ParseSeries parses a time interval such as "5m", a bar identifier such as "volume:10" or a
+// Heikin-Ashi series such as "heikin_ashi:1h" into the canonical Interval of its candles
+func ParseSeries(s string) (Interval, error) {
+	if !strings.Contains(s, ":") {
+		return ParseInterval(s)
+	}
+	if interval, ok := strings.CutPrefix(s, string(BarTypeHeikinAshi)+":"); ok {
+		source, err := ParseInterval(interval)
+		if err != nil {
+			return "", err
+		}
+		return HeikinAshiInterval(source), nil
+	}
+	spec, err := ParseBarSpec(s)
+	if err != nil {
+		return "", err
+	}
+	return spec.Interval(), nil
+}
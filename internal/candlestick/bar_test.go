@@ -0,0 +1,162 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBarSpec(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Interval
+		wantErr  bool
+	}{
+		{input: "tick:100", expected: "tick:100"},
+		{input: "volume:2.50", expected: "volume:2.5"},
+		{input: "dollar:1e6", expected: "dollar:1000000"},
+		{input: "range:0.50", expected: "range:0.5"},
+		{input: "renko:10", expected: "renko:10"},
+		{input: "heikin_ashi:1h", expected: "heikin_ashi:1h"},
+		{input: "5m", expected: Interval5m},
+		{input: "tick:1.5", wantErr: true},
+		{input: "volume:0", wantErr: true},
+		{input: "heikin_ashi:2h", wantErr: true},
+		{input: "kagi:10", wantErr: true},
+		{input: "dollar:lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			interval, err := ParseSeries(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if interval != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, interval)
+			}
+		})
+	}
+}
+
+func TestProcessBars(t *testing.T) {
+	specs := make([]BarSpec, 0, 3)
+	for _, s := range []string{"tick:4", "volume:3", "dollar:500"} {
+		spec, err := ParseBarSpec(s)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		specs = append(specs, spec)
+	}
+	agg := NewAggregator(AggregatorConfig{Bars: map[Symbol][]BarSpec{BTCUSDT: specs}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	completed := make(map[Interval][]*OHLC)
+	for i, price := range []string{"100", "110", "90", "120", "105"} {
+		id := int64(2*i + 1)
+		tick := Tick{Symbol: BTCUSDT, Price: dec(price), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Hour), FirstTradeID: id, LastTradeID: id + 1}
+		ohlcs, err := agg.Process(tick)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, ohlc := range ohlcs {
+			completed[ohlc.Interval] = append(completed[ohlc.Interval], ohlc)
+		}
+	}
+
+	// Every tick carries two trades, so the second tick completes the tick bar
+	if bars := completed["tick:4"]; len(bars) != 2 || bars[0].Trades != 4 || !bars[0].Close.Equal(dec("110")) {
+		t.Errorf("Unexpected tick bars: %+v", bars)
+	}
+	// The volume bar closes on the third tick, spanning the timestamps of its ticks
+	bars := completed["volume:3"]
+	if len(bars) != 1 || !bars[0].Volume.Equal(dec("3")) || !bars[0].Low.Equal(dec("90")) {
+		t.Fatalf("Unexpected volume bars: %+v", bars)
+	}
+	if bars[0].BarType != BarTypeVolume || !bars[0].OpenTime.Equal(start) || !bars[0].CloseTime.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Unexpected volume bar: %+v", bars[0])
+	}
+	// The crossing tick belongs to the bar it completes: 100 + 110 + 90 + 120 + 105 >= 500
+	if bars := completed["dollar:500"]; len(bars) != 1 || !bars[0].QuoteVolume.Equal(dec("525")) {
+		t.Errorf("Unexpected dollar bars: %+v", bars)
+	}
+
+	// Bars never close on time
+	if flushed := agg.Flush(start.Add(24 * time.Hour)); len(flushed) != 0 {
+		t.Errorf("Expected no bars to be flushed, got %+v", flushed)
+	}
+	if current := agg.Current(BTCUSDT, "volume:3"); current == nil || !current.Volume.Equal(dec("2")) {
+		t.Errorf("Expected an in-progress volume bar, got %+v", current)
+	}
+}
+
+func TestProcessRangeBars(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Bars: map[Symbol][]BarSpec{BTCUSDT: {{Type: BarTypeRange, Threshold: dec("5")}}}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var bars []*OHLC
+	for i, price := range []string{"100", "103", "98", "99", "104", "101"} {
+		ohlcs, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec(price), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Second)})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bars = append(bars, ohlcs...)
+	}
+
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 range bars, got %+v", bars)
+	}
+	if !bars[0].High.Equal(dec("103")) || !bars[0].Low.Equal(dec("98")) || !bars[0].Volume.Equal(dec("3")) {
+		t.Errorf("Unexpected first range bar: %+v", bars[0])
+	}
+	if !bars[1].Open.Equal(dec("99")) || !bars[1].Close.Equal(dec("104")) || bars[1].BarType != BarTypeRange {
+		t.Errorf("Unexpected second range bar: %+v", bars[1])
+	}
+}
+
+func TestProcessRenkoBricks(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Bars: map[Symbol][]BarSpec{BTCUSDT: {{Type: BarTypeRenko, Threshold: dec("10")}}}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var bricks []*OHLC
+	for i, price := range []string{"100", "105", "112", "125", "111", "95", "70"} {
+		id := int64(i + 1)
+		ohlcs, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec(price), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Second), FirstTradeID: id, LastTradeID: id})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bricks = append(bricks, ohlcs...)
+	}
+
+	// 112 and 125 form two up bricks, reversing takes two bricks so 111 forms none, 95 forms
+	// one down brick from the bottom of the last brick and 70 forms three more
+	expected := [][2]string{{"100", "110"}, {"110", "120"}, {"110", "100"}, {"100", "90"}, {"90", "80"}, {"80", "70"}}
+	if len(bricks) != len(expected) {
+		t.Fatalf("Expected %d bricks, got %d: %+v", len(expected), len(bricks), bricks)
+	}
+	for i, brick := range bricks {
+		if !brick.Open.Equal(dec(expected[i][0])) || !brick.Close.Equal(dec(expected[i][1])) {
+			t.Errorf("Brick %d: expected %s to %s, got %s to %s", i, expected[i][0], expected[i][1], brick.Open, brick.Close)
+		}
+	}
+
+	// Volume belongs to the first brick a tick forms
+	for i, volume := range []string{"3", "1", "2", "1", "0", "0"} {
+		if !bricks[i].Volume.Equal(dec(volume)) {
+			t.Errorf("Brick %d: expected volume %s, got %s", i, volume, bricks[i].Volume)
+		}
+	}
+
+	// The next brick starts from the last one
+	current := agg.Current(BTCUSDT, "renko:10")
+	if current == nil || !current.Open.Equal(dec("70")) || current.FirstTradeID != 8 {
+		t.Fatalf("Unexpected pending brick: %+v", current)
+	}
+
+	// Bricks formed by the same tick, and the brick pending after them, open a microsecond apart
+	last := start.Add(6 * time.Second)
+	for i, brick := range append(bricks[4:], current) {
+		if expected := last.Add(time.Duration(i) * time.Microsecond); !brick.OpenTime.Equal(expected) || brick.CloseTime.Before(brick.OpenTime) {
+			t.Errorf("Brick %d: expected to open at %v, got %v to %v", i+4, expected, brick.OpenTime, brick.CloseTime)
+		}
+	}
+}
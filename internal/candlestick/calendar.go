@@ -0,0 +1,153 @@
+package candlestick
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calendar aligns candles to wall-clock boundaries in a time zone: days start at local midnight,
+// weeks on WeekStart and months on the first day of the month. Intervals shorter than a day start
+// when the wall clock reaches a multiple of the interval since local midnight; across a daylight
+// saving transition a candle spanning a skipped boundary closes at the transition, and a repeated
+// hour opens candles on both occurrences of its boundaries. The zero value aligns to UTC with
+// weeks starting on Sunday
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
+
+// DefaultCalendar aligns to UTC with ISO weeks starting on Monday
+var DefaultCalendar = Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+// NewCalendar creates a calendar for the named IANA time zone and week start day, e.g.
+// "Asia/Jakarta" and "monday"
+func NewCalendar(timezone, weekStart string) (Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+	}
+
+	calendar := Calendar{Location: location, WeekStart: time.Monday}
+	if weekStart == "" {
+		return calendar, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), weekStart) {
+			calendar.WeekStart = day
+			return calendar, nil
+		}
+	}
+	return Calendar{}, fmt.Errorf("invalid week start %q", weekStart)
+}
+
+// location returns the calendar's time zone, defaulting to UTC
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// Truncate returns the open time, in UTC, of the interval's candle containing t
+func (c Calendar) Truncate(interval Interval, t time.Time) time.Time {
+	local := t.In(c.location())
+	switch interval {
+	case Interval1d:
+		return startOfDay(local).UTC()
+	case Interval1w:
+		day := startOfDay(local)
+		return day.AddDate(0, 0, -int((day.Weekday()-c.WeekStart+7)%7)).UTC()
+	case Interval1M:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, local.Location()).UTC()
+	}
+
+	return truncateWall(interval.Duration(), local).UTC()
+}
+
+// Next returns the close time, in UTC, of the interval's candle opening at start
+func (c Calendar) Next(interval Interval, start time.Time) time.Time {
+	local := start.In(c.location())
+	switch interval {
+	case Interval1d:
+		return local.AddDate(0, 0, 1).UTC()
+	case Interval1w:
+		return local.AddDate(0, 0, 7).UTC()
+	case Interval1M:
+		return local.AddDate(0, 1, 0).UTC()
+	}
+	return nextWall(interval.Duration(), local).UTC()
+}
+
+// truncateWall returns the latest sub-day candle boundary at or before the local time t
+func truncateWall(d time.Duration, t time.Time) time.Time {
+	start := t.Add(-(wallClock(t) % d))
+	if sameOffset(start, t) {
+		return start
+	}
+
+	// The wall clock jumped since the candidate start, so the candle opened at the transition or
+	// before it
+	transition := findTransition(start, t)
+	if isWallBoundary(d, transition) {
+		return transition
+	}
+	return truncateWall(d, transition.Add(-time.Nanosecond))
+}
+
+// nextWall returns the first sub-day candle boundary after the local time start
+func nextWall(d time.Duration, start time.Time) time.Time {
+	next := start.Add(d - wallClock(start)%d)
+	if sameOffset(start, next) {
+		return next
+	}
+
+	transition := findTransition(start, next)
+	if isWallBoundary(d, transition) {
+		return transition
+	}
+	return nextWall(d, transition)
+}
+
+// isWallBoundary reports whether a daylight saving transition opens a sub-day candle, either
+// because the wall clock lands on a boundary or because it skips one
+func isWallBoundary(d time.Duration, transition time.Time) bool {
+	_, before := transition.Add(-time.Nanosecond).Zone()
+	_, after := transition.Zone()
+	wall := wallClock(transition)
+	skipped := wall - time.Duration(after-before)*time.Second
+	return wall-wall%d >= min(wall, skipped)
+}
+
+// findTransition returns the first instant after from with the time zone offset of to, given
+// that their offsets differ
+func findTransition(from, to time.Time) time.Time {
+	for to.Sub(from) > time.Second {
+		mid := from.Add(to.Sub(from) / 2)
+		if sameOffset(from, mid) {
+			from = mid
+		} else {
+			to = mid
+		}
+	}
+	// Transitions happen on whole seconds
+	return to.Truncate(time.Second)
+}
+
+// sameOffset reports whether two times of a location share its offset from UTC
+func sameOffset(a, b time.Time) bool {
+	_, offsetA := a.Zone()
+	_, offsetB := b.Zone()
+	return offsetA == offsetB
+}
+
+// wallClock returns the local time of day of t
+func wallClock(t time.Time) time.Duration {
+	hour, minute, second := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// startOfDay returns local midnight of the day containing t
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,119 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarTruncate(t *testing.T) {
+	jakarta, err := NewCalendar("Asia/Jakarta", "monday")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sunday, err := NewCalendar("UTC", "Sunday")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Wednesday 2025-01-15 02:30 in Jakarta (UTC+7)
+	ts := time.Date(2025, 1, 14, 19, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		calendar Calendar
+		interval Interval
+		open     time.Time
+		close    time.Time
+	}{
+		{"utc 4h", DefaultCalendar, Interval4h, time.Date(2025, 1, 14, 16, 0, 0, 0, time.UTC), time.Date(2025, 1, 14, 20, 0, 0, 0, time.UTC)},
+		{"local 4h", jakarta, Interval4h, time.Date(2025, 1, 14, 17, 0, 0, 0, time.UTC), time.Date(2025, 1, 14, 21, 0, 0, 0, time.UTC)},
+		{"utc day", DefaultCalendar, Interval1d, time.Date(2025, 1, 14, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"local day", jakarta, Interval1d, time.Date(2025, 1, 14, 17, 0, 0, 0, time.UTC), time.Date(2025, 1, 15, 17, 0, 0, 0, time.UTC)},
+		{"monday week", DefaultCalendar, Interval1w, time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"sunday week", sunday, Interval1w, time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"local week", jakarta, Interval1w, time.Date(2025, 1, 12, 17, 0, 0, 0, time.UTC), time.Date(2025, 1, 19, 17, 0, 0, 0, time.UTC)},
+		{"utc month", DefaultCalendar, Interval1M, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"local month", jakarta, Interval1M, time.Date(2024, 12, 31, 17, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open := tt.calendar.Truncate(tt.interval, ts)
+			if !open.Equal(tt.open) {
+				t.Errorf("Expected open time %s, got %s", tt.open, open)
+			}
+			if close := tt.calendar.Next(tt.interval, open); !close.Equal(tt.close) {
+				t.Errorf("Expected close time %s, got %s", tt.close, close)
+			}
+		})
+	}
+
+	if _, err := NewCalendar("UTC", "someday"); err == nil {
+		t.Error("Expected an error for an invalid week start")
+	}
+}
+
+func TestCalendarDaylightSaving(t *testing.T) {
+	newYork, err := NewCalendar("America/New_York", "monday")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	utc := func(day, hour int) time.Time {
+		return time.Date(2025, time.Month(day/100), day%100, hour, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		interval Interval
+		opens    []time.Time // consecutive open times from local midnight
+	}{
+		// Clocks skip from 02:00 EST to 03:00 EDT, the 4h candle opening at midnight closes at
+		// 04:00 EDT, an hour early
+		{"spring 4h", Interval4h, []time.Time{utc(309, 5), utc(309, 8), utc(309, 12), utc(309, 16), utc(309, 20), utc(310, 0), utc(310, 4)}},
+		// The 02:00 boundary is skipped, so the candle opens at 03:00 EDT
+		{"spring 1h", Interval1h, []time.Time{utc(309, 5), utc(309, 6), utc(309, 7), utc(309, 8)}},
+		// Clocks fall back from 02:00 EDT to 01:00 EST, the 4h candle opening at midnight closes
+		// at 04:00 EST, an hour late
+		{"fall 4h", Interval4h, []time.Time{utc(1102, 4), utc(1102, 9), utc(1102, 13), utc(1102, 17), utc(1102, 21), utc(1103, 1), utc(1103, 5)}},
+		// Both 01:00 EDT and 01:00 EST open a 1h candle
+		{"fall 1h", Interval1h, []time.Time{utc(1102, 4), utc(1102, 5), utc(1102, 6), utc(1102, 7), utc(1102, 8)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 1; i < len(tt.opens); i++ {
+				open, close := tt.opens[i-1], tt.opens[i]
+				if next := newYork.Next(tt.interval, open); !next.Equal(close) {
+					t.Errorf("Expected the candle opening at %s to close at %s, got %s", open, close, next)
+				}
+				// Every minute of the candle truncates to its open time
+				for ts := open; ts.Before(close); ts = ts.Add(time.Minute) {
+					if truncated := newYork.Truncate(tt.interval, ts); !truncated.Equal(open) {
+						t.Fatalf("Expected %s to truncate to %s, got %s", ts, open, truncated)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestProcessMonthlyCandles(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1M}, Calendar: DefaultCalendar})
+
+	// February is shorter than the nominal 30 days of a month
+	feb := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+	if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: feb}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	current := agg.Current(BTCUSDT, Interval1M)
+	if !current.OpenTime.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) || !current.CloseTime.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected monthly candle from %s to %s", current.OpenTime, current.CloseTime)
+	}
+
+	completed, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("101"), Quantity: dec("1"), Timestamp: time.Date(2025, 3, 1, 0, 0, 1, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 1 || !completed[0].Close.Equal(dec("100")) {
+		t.Fatalf("Expected the February candle to complete, got %+v", completed)
+	}
+}
@@ -0,0 +1,31 @@
+package candlestick
+
+import (
+	"time"
+)
+
+// Clock abstracts the wall clock so candle closing can be driven deterministically in tests
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock implements the Clock interface using the time package
+type systemClock struct{}
+
+// NewSystemClock creates a clock backed by the system time
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+// Now implements Clock.Now
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Clock.After
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
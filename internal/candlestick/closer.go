@@ -0,0 +1,101 @@
+package candlestick
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Flusher completes candles whose close time has passed
+type Flusher interface {
+	// Flush completes and returns every in-progress OHLC whose close time is at or before now
+	Flush(now time.Time) []*OHLC
+}
+
+// Closer finalizes in-progress candles at their interval boundaries, so candles of
+// illiquid symbols are completed on time instead of waiting for the next tick
+type Closer struct {
+	flushers  []Flusher
+	clock     Clock
+	calendar  Calendar
+	intervals []Interval
+	delay     time.Duration
+	locker    sync.Locker             // held while a flusher is flushed and its candles emitted, nil for none
+	lockers   map[Flusher]sync.Locker // held instead of locker for some flushers
+}
+
+// NewCloser creates a closer that flushes each flusher in turn on every calendar boundary of
+// the intervals, waiting delay after each boundary for in-flight ticks to arrive
+func NewCloser(clock Clock, calendar Calendar, intervals []Interval, delay time.Duration, flushers ...Flusher) *Closer {
+	if len(intervals) == 0 {
+		intervals = []Interval{Interval1s}
+	}
+
+	return &Closer{
+		flushers:  flushers,
+		clock:     clock,
+		calendar:  calendar,
+		intervals: intervals,
+		delay:     delay,
+		lockers:   make(map[Flusher]sync.Locker),
+	}
+}
+
+// SetLocker makes Run hold locker from flushing each flusher until its candles are emitted, so
+// that whatever else takes the lock sees either none or all of them
+func (c *Closer) SetLocker(locker sync.Locker) {
+	c.locker = locker
+}
+
+// SetFlusherLocker makes Run hold locker instead of the one set by SetLocker while flushing
+// flusher and emitting its candles, so that a flusher consuming candles emitted elsewhere can
+// wait for them
+func (c *Closer) SetFlusherLocker(flusher Flusher, locker sync.Locker) {
+	c.lockers[flusher] = locker
+}
+
+// Run flushes completed candles to emit until ctx is cancelled; the candles of a flusher are
+// emitted before the next flusher is flushed, so it can consume them first
+func (c *Closer) Run(ctx context.Context, emit func(*OHLC)) {
+	for {
+		now := c.clock.Now()
+		boundary := c.nextBoundary(now.Add(-c.delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-c.clock.After(boundary.Add(c.delay).Sub(now)):
+		}
+
+		for _, flusher := range c.flushers {
+			c.flush(flusher, now.Add(-c.delay), emit)
+		}
+	}
+}
+
+// flush emits the candles a flusher completes by now, holding its locker if there is one
+func (c *Closer) flush(flusher Flusher, now time.Time, emit func(*OHLC)) {
+	locker, ok := c.lockers[flusher]
+	if !ok {
+		locker = c.locker
+	}
+	if locker != nil {
+		locker.Lock()
+		defer locker.Unlock()
+	}
+	for _, ohlc := range flusher.Flush(now) {
+		emit(ohlc)
+	}
+}
+
+// nextBoundary returns the earliest close time after t of any interval's candle
+func (c *Closer) nextBoundary(t time.Time) time.Time {
+	var boundary time.Time
+	for _, interval := range c.intervals {
+		next := c.calendar.Next(interval, c.calendar.Truncate(interval, t))
+		if boundary.IsZero() || next.Before(boundary) {
+			boundary = next
+		}
+	}
+	return boundary
+}
@@ -0,0 +1,186 @@
+package candlestick
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m, Interval5m}})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := agg.Process(Tick{Symbol: PEPEUSDT, Price: dec("0.00001"), Quantity: dec("100"), Timestamp: start.Add(30 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Nothing is complete before the first boundary
+	if completed := agg.Flush(start.Add(59 * time.Second)); len(completed) != 0 {
+		t.Fatalf("Expected no completed candles, got %d", len(completed))
+	}
+
+	// The 1m candle closes at its boundary without any further tick
+	completed := agg.Flush(start.Add(time.Minute))
+	if len(completed) != 1 || completed[0].Interval != Interval1m {
+		t.Fatalf("Expected the 1m candle to complete, got %+v", completed)
+	}
+
+	// Flushing again must not emit the same candle twice
+	if completed := agg.Flush(start.Add(2 * time.Minute)); len(completed) != 0 {
+		t.Fatalf("Expected no completed candles, got %d", len(completed))
+	}
+
+	// A late tick for the closed 1m candle is dropped, but still counts towards the 5m candle
+	if _, err := agg.Process(Tick{Symbol: PEPEUSDT, Price: dec("0.00002"), Quantity: dec("50"), Timestamp: start.Add(40 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	completed = agg.Flush(start.Add(5 * time.Minute))
+	if len(completed) != 1 || completed[0].Interval != Interval5m {
+		t.Fatalf("Expected the 5m candle to complete, got %+v", completed)
+	}
+	if !completed[0].Volume.Equal(dec("150")) || !completed[0].High.Equal(dec("0.00002")) {
+		t.Errorf("Unexpected 5m candle: %+v", completed[0])
+	}
+}
+
+func TestCloserRun(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+	intervals := []Interval{Interval1m}
+	agg := NewAggregator(AggregatorConfig{Intervals: intervals})
+
+	for _, symbol := range []Symbol{BTCUSDT, ETHUSDT} {
+		if _, err := agg.Process(Tick{Symbol: symbol, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	emitted := make(chan *OHLC, 10)
+	closer := NewCloser(clock, DefaultCalendar, intervals, time.Second, agg)
+	go closer.Run(ctx, func(ohlc *OHLC) {
+		emitted <- ohlc
+	})
+
+	// Reaching the boundary is not enough, the closer waits for the delay to pass
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	select {
+	case ohlc := <-emitted:
+		t.Fatalf("Unexpected candle before the close delay: %+v", ohlc)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	for _, symbol := range []Symbol{BTCUSDT, ETHUSDT} {
+		select {
+		case ohlc := <-emitted:
+			if ohlc.Symbol != symbol {
+				t.Errorf("Expected candle for %s, got %s", symbol, ohlc.Symbol)
+			}
+			if !ohlc.CloseTime.Equal(start.Add(time.Minute)) {
+				t.Errorf("Unexpected close time %s", ohlc.CloseTime)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %s candle", symbol)
+		}
+	}
+}
+
+func TestCloserRunLocker(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+	intervals := []Interval{Interval1m}
+	agg := NewAggregator(AggregatorConfig{Intervals: intervals})
+
+	if _, err := agg.Process(Tick{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The candles are emitted while the locker is held
+	var mu sync.Mutex
+	locked := make(chan bool, 1)
+	closer := NewCloser(clock, DefaultCalendar, intervals, 0, agg)
+	closer.SetLocker(&mu)
+	go closer.Run(ctx, func(ohlc *OHLC) {
+		free := mu.TryLock()
+		if free {
+			mu.Unlock()
+		}
+		locked <- !free
+	})
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case held := <-locked:
+		if !held {
+			t.Error("Expected the candle to be emitted with the locker held")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the candle")
+	}
+
+	// And released once they are
+	clock.BlockUntil(1)
+	if !mu.TryLock() {
+		t.Error("Expected the locker to be released after the flush")
+	}
+}
+
+func TestCloserRunFlusherLocker(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+	intervals := []Interval{Interval1m}
+	first := NewAggregator(AggregatorConfig{Intervals: intervals})
+	second := NewAggregator(AggregatorConfig{Intervals: intervals})
+
+	if _, err := first.Process(Tick{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := second.Process(Tick{Symbol: ETHUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(10 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The second flusher is flushed holding its own locker instead of the shared one
+	var shared, own sync.Mutex
+	held := func(mu *sync.Mutex) bool {
+		if mu.TryLock() {
+			mu.Unlock()
+			return false
+		}
+		return true
+	}
+	type locks struct{ shared, own bool }
+	emitted := make(chan locks, 2)
+	closer := NewCloser(clock, DefaultCalendar, intervals, 0, first, second)
+	closer.SetLocker(&shared)
+	closer.SetFlusherLocker(second, &own)
+	go closer.Run(ctx, func(ohlc *OHLC) {
+		emitted <- locks{shared: held(&shared), own: held(&own)}
+	})
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	for _, expected := range []locks{{shared: true}, {own: true}} {
+		select {
+		case got := <-emitted:
+			if got != expected {
+				t.Errorf("Expected locks %+v, got %+v", expected, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for the candle")
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package candlestick
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultValueArea is the share of a footprint's volume its value area covers
+var DefaultValueArea = decimal.RequireFromString("0.7")
+
+// FootprintLevel holds the volume traded at one price level of a footprint, split by aggressor
+type FootprintLevel struct {
+	Price      decimal.Decimal `json:"price"`       // lower bound of the level
+	BuyVolume  decimal.Decimal `json:"buy_volume"`  // base volume bought by aggressive buyers
+	SellVolume decimal.Decimal `json:"sell_volume"` // base volume sold by aggressive sellers
+}
+
+// Volume returns the total volume traded at the level
+func (l FootprintLevel) Volume() decimal.Decimal {
+	return l.BuyVolume.Add(l.SellVolume)
+}
+
+// Footprint is the volume profile of a candle: its volume bucketed by price level and split into
+// buy and sell volume, with the point of control and value area. Footprints of a daily interval
+// are the session's volume profile
+type Footprint struct {
+	Symbol        Symbol           `json:"symbol" gorm:"column:symbol"`
+	Interval      Interval         `json:"interval" gorm:"column:interval"`
+	OpenTime      time.Time        `json:"open_time" gorm:"column:open_time"`
+	CloseTime     time.Time        `json:"close_time" gorm:"column:close_time"`
+	TickSize      decimal.Decimal  `json:"tick_size" gorm:"column:tick_size;type:numeric"`             // price range of a level
+	Levels        []FootprintLevel `json:"levels" gorm:"column:levels;serializer:json;type:jsonb"`     // levels with volume, by ascending price
+	POC           decimal.Decimal  `json:"poc" gorm:"column:poc;type:numeric"`                         // point of control, the level with the most volume
+	ValueAreaHigh decimal.Decimal  `json:"value_area_high" gorm:"column:value_area_high;type:numeric"` // upper bound of the value area
+	ValueAreaLow  decimal.Decimal  `json:"value_area_low" gorm:"column:value_area_low;type:numeric"`   // lower bound of the value area
+	IsClosed      bool             `json:"is_closed" gorm:"-"`
+}
+
+// FootprintConfig configures the footprints built alongside the candles
+type FootprintConfig struct {
+	Intervals  []Interval                 // intervals with footprints, closed with their candles
+	TickSizes  map[Symbol]decimal.Decimal // price range of a level per symbol, defaulting to 100 price units
+	ValueArea  decimal.Decimal            // share of the volume in the value area, defaulting to DefaultValueArea
+	Precisions Precisions
+	Calendar   Calendar
+}
+
+// footprintKey identifies the footprint of a candle
+type footprintKey struct {
+	symbol   Symbol
+	interval Interval
+	openTime time.Time
+}
+
+// FootprintAggregator builds the footprints of the candles in progress from ticks
+type FootprintAggregator struct {
+	mu         sync.Mutex
+	config     FootprintConfig
+	footprints map[footprintKey]*Footprint
+	dirty      map[footprintKey]bool
+	closed     map[candleKey]time.Time // close time of the last closed footprint of each series
+}
+
+// NewFootprintAggregator creates a footprint aggregator
+func NewFootprintAggregator(config FootprintConfig) *FootprintAggregator {
+	if config.ValueArea.IsZero() {
+		config.ValueArea = DefaultValueArea
+	}
+
+	return &FootprintAggregator{
+		config:     config,
+		footprints: make(map[footprintKey]*Footprint),
+		dirty:      make(map[footprintKey]bool),
+		closed:     make(map[candleKey]time.Time),
+	}
+}
+
+// tickSize returns the configured tick size of a symbol, or 100 units of its price precision
+func (f *FootprintAggregator) tickSize(symbol Symbol) decimal.Decimal {
+	if tickSize, ok := f.config.TickSizes[symbol]; ok {
+		return tickSize
+	}
+	return decimal.New(100, -f.config.Precisions.For(symbol).Price)
+}
+
+// Process adds a tick to the footprints of its candles, leaving out late ticks of closed ones
+func (f *FootprintAggregator) Process(tick Tick) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tickSize := f.tickSize(tick.Symbol)
+	price := tick.Price.Div(tickSize).Floor().Mul(tickSize)
+
+	for _, interval := range f.config.Intervals {
+		openTime := f.config.Calendar.Truncate(interval, tick.Timestamp)
+		if openTime.Before(f.closed[candleKey{symbol: tick.Symbol, interval: interval}]) {
+			continue
+		}
+		key := footprintKey{symbol: tick.Symbol, interval: interval, openTime: openTime}
+
+		footprint, exists := f.footprints[key]
+		if !exists {
+			footprint = &Footprint{
+				Symbol:    tick.Symbol,
+				Interval:  interval,
+				OpenTime:  openTime,
+				CloseTime: f.config.Calendar.Next(interval, openTime),
+				TickSize:  tickSize,
+			}
+			f.footprints[key] = footprint
+		}
+
+		i, found := slices.BinarySearchFunc(footprint.Levels, price, func(level FootprintLevel, price decimal.Decimal) int {
+			return level.Price.Cmp(price)
+		})
+		if !found {
+			footprint.Levels = slices.Insert(footprint.Levels, i, FootprintLevel{Price: price})
+		}
+		if tick.IsBuyerMaker {
+			footprint.Levels[i].SellVolume = footprint.Levels[i].SellVolume.Add(tick.Quantity)
+		} else {
+			footprint.Levels[i].BuyVolume = footprint.Levels[i].BuyVolume.Add(tick.Quantity)
+		}
+		f.dirty[key] = true
+	}
+}
+
+// Close completes the footprint of a closed candle, returning nil for candles without trades or
+// revisions. Footprints are not revised: late ticks correcting a closed candle are left out of
+// its footprint. Footprints of earlier candles that were never closed are discarded
+func (f *FootprintAggregator) Close(ohlc *OHLC) *Footprint {
+	if !ohlc.IsClosed || ohlc.Revision > 0 || ohlc.Synthetic {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key := range f.footprints {
+		if key.symbol == ohlc.Symbol && key.interval == ohlc.Interval && key.openTime.Before(ohlc.OpenTime) {
+			log.Printf("Discarding %s footprint for symbol=%s at time=%s without a closed candle", key.interval, key.symbol, key.openTime.Format(time.RFC3339))
+			delete(f.footprints, key)
+			delete(f.dirty, key)
+		}
+	}
+
+	series := candleKey{symbol: ohlc.Symbol, interval: ohlc.Interval}
+	if closeTime := f.config.Calendar.Next(ohlc.Interval, ohlc.OpenTime); closeTime.After(f.closed[series]) {
+		f.closed[series] = closeTime
+	}
+
+	key := footprintKey{symbol: ohlc.Symbol, interval: ohlc.Interval, openTime: ohlc.OpenTime}
+	footprint, ok := f.footprints[key]
+	if !ok {
+		return nil
+	}
+	delete(f.footprints, key)
+	delete(f.dirty, key)
+
+	footprint.complete(f.config.ValueArea)
+	footprint.IsClosed = true
+	return footprint
+}
+
+// Prune discards the footprints of candles that closed before now, which were rebuilt from ticks
+// of candles already published before a restart
+func (f *FootprintAggregator) Prune(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key, footprint := range f.footprints {
+		if !footprint.CloseTime.After(now) {
+			delete(f.footprints, key)
+			delete(f.dirty, key)
+		}
+	}
+}
+
+// Updates returns copies of the in-progress footprints that changed since the last call
+func (f *FootprintAggregator) Updates() []*Footprint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	updates := make([]*Footprint, 0, len(f.dirty))
+	for key := range f.dirty {
+		copy := *f.footprints[key]
+		copy.Levels = slices.Clone(copy.Levels)
+		copy.complete(f.config.ValueArea)
+		updates = append(updates, &copy)
+	}
+	clear(f.dirty)
+
+	slices.SortFunc(updates, func(a, b *Footprint) int {
+		return a.OpenTime.Compare(b.OpenTime)
+	})
+	return updates
+}
+
+// complete computes the point of control and the value area, grown from the point of control
+// one level at a time towards the side with more volume until it covers the value area share
+func (fp *Footprint) complete(valueArea decimal.Decimal) {
+	if len(fp.Levels) == 0 {
+		return
+	}
+
+	total := decimal.Zero
+	poc := 0
+	for i, level := range fp.Levels {
+		total = total.Add(level.Volume())
+		if level.Volume().GreaterThan(fp.Levels[poc].Volume()) {
+			poc = i
+		}
+	}
+
+	target := total.Mul(valueArea)
+	low, high := poc, poc
+	covered := fp.Levels[poc].Volume()
+	for covered.LessThan(target) && (low > 0 || high < len(fp.Levels)-1) {
+		below, above := decimal.Zero, decimal.Zero
+		if low > 0 {
+			below = fp.Levels[low-1].Volume()
+		}
+		if high < len(fp.Levels)-1 {
+			above = fp.Levels[high+1].Volume()
+		}
+
+		if high == len(fp.Levels)-1 || (low > 0 && below.GreaterThan(above)) {
+			low--
+			covered = covered.Add(below)
+		} else {
+			high++
+			covered = covered.Add(above)
+		}
+	}
+
+	fp.POC = fp.Levels[poc].Price
+	fp.ValueAreaLow = fp.Levels[low].Price
+	fp.ValueAreaHigh = fp.Levels[high].Price.Add(fp.TickSize)
+}
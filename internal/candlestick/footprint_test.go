@@ -0,0 +1,97 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFootprintAggregator(t *testing.T) {
+	footprints := NewFootprintAggregator(FootprintConfig{
+		Intervals: []Interval{Interval1m},
+		TickSizes: map[Symbol]decimal.Decimal{BTCUSDT: dec("10")},
+		Calendar:  DefaultCalendar,
+	})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	trades := []struct {
+		price    string
+		quantity string
+		sell     bool
+	}{
+		{"100", "1", false},
+		{"105", "2", true},
+		{"112", "4", false},
+		{"95", "1", true},
+		{"121", "2", false},
+	}
+	for i, trade := range trades {
+		footprints.Process(Tick{Symbol: BTCUSDT, Price: dec(trade.price), Quantity: dec(trade.quantity), IsBuyerMaker: trade.sell, Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	updates := footprints.Updates()
+	if len(updates) != 1 || updates[0].IsClosed || !updates[0].POC.Equal(dec("110")) {
+		t.Fatalf("Unexpected footprint updates: %+v", updates)
+	}
+	if updates := footprints.Updates(); len(updates) != 0 {
+		t.Errorf("Expected no updates without new ticks, got %+v", updates)
+	}
+
+	footprint := footprints.Close(&OHLC{Symbol: BTCUSDT, Interval: Interval1m, OpenTime: start, IsClosed: true})
+	if footprint == nil || !footprint.IsClosed || !footprint.CloseTime.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Unexpected closed footprint: %+v", footprint)
+	}
+
+	expected := []FootprintLevel{
+		{Price: dec("90"), SellVolume: dec("1")},
+		{Price: dec("100"), BuyVolume: dec("1"), SellVolume: dec("2")},
+		{Price: dec("110"), BuyVolume: dec("4")},
+		{Price: dec("120"), BuyVolume: dec("2")},
+	}
+	if len(footprint.Levels) != len(expected) {
+		t.Fatalf("Expected %d levels, got %+v", len(expected), footprint.Levels)
+	}
+	for i, level := range footprint.Levels {
+		if !level.Price.Equal(expected[i].Price) || !level.BuyVolume.Equal(expected[i].BuyVolume) || !level.SellVolume.Equal(expected[i].SellVolume) {
+			t.Errorf("Level %d: expected %+v, got %+v", i, expected[i], level)
+		}
+	}
+
+	// The value area grows from the point of control towards the level at 100, which holds more
+	// volume than the one at 120, and then covers 7 of the 10 traded
+	if !footprint.POC.Equal(dec("110")) || !footprint.ValueAreaLow.Equal(dec("100")) || !footprint.ValueAreaHigh.Equal(dec("120")) {
+		t.Errorf("Unexpected point of control %s and value area %s-%s", footprint.POC, footprint.ValueAreaLow, footprint.ValueAreaHigh)
+	}
+
+	if footprint := footprints.Close(&OHLC{Symbol: BTCUSDT, Interval: Interval1m, OpenTime: start, IsClosed: true}); footprint != nil {
+		t.Errorf("Expected a footprint to close only once, got %+v", footprint)
+	}
+
+	// Late ticks of the closed candle are left out of its footprint
+	footprints.Process(Tick{Symbol: BTCUSDT, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(30 * time.Second)})
+	if updates := footprints.Updates(); len(updates) != 0 {
+		t.Errorf("Expected no footprint for a late tick, got %+v", updates)
+	}
+}
+
+func TestFootprintAggregatorPrune(t *testing.T) {
+	footprints := NewFootprintAggregator(FootprintConfig{Intervals: []Interval{Interval1m}, Precisions: DefaultPrecisions, Calendar: DefaultCalendar})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		footprints.Process(Tick{Symbol: BTCUSDT, Price: dec("100123.45"), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	// Footprints rebuilt for candles that closed before the restart are dropped
+	footprints.Prune(start.Add(2 * time.Minute))
+	updates := footprints.Updates()
+	if len(updates) != 1 || !updates[0].OpenTime.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("Expected only the last footprint to remain, got %+v", updates)
+	}
+
+	// Without a configured tick size, levels span 100 units of the price precision
+	if !updates[0].TickSize.Equal(dec("1")) || !updates[0].POC.Equal(dec("100123")) {
+		t.Errorf("Unexpected default tick size %s and point of control %s", updates[0].TickSize, updates[0].POC)
+	}
+}
@@ -0,0 +1,131 @@
+package candlestick
+
+import (
+	"io"
+	"slices"
+	"time"
+)
+
+// flatCandle creates a synthetic zero-volume candle following prev, with every price
+// set to prev's close
+func flatCandle(prev *OHLC, interval Interval, calendar Calendar) *OHLC {
+	return &OHLC{
+		Symbol:    prev.Symbol,
+		Interval:  interval,
+		Open:      prev.Close,
+		High:      prev.Close,
+		Low:       prev.Close,
+		Close:     prev.Close,
+		VWAP:      prev.Close,
+		OpenTime:  prev.CloseTime,
+		CloseTime: calendar.Next(interval, prev.CloseTime),
+		BarType:   BarTypeTime,
+		Synthetic: true,
+		IsClosed:  true,
+	}
+}
+
+// FillGaps inserts synthetic candles for every missing interval between consecutive
+// candles; candles must share a symbol and interval, be aligned to calendar and be sorted by open time
+func FillGaps(candles []*OHLC, calendar Calendar) []*OHLC {
+	// Bars have no fixed length, so there is nothing to fill
+	if len(candles) < 2 || candles[0].Interval.IsBar() {
+		return candles
+	}
+
+	filled := make([]*OHLC, 0, len(candles))
+	filled = append(filled, candles[0])
+	for _, ohlc := range candles[1:] {
+		prev := filled[len(filled)-1]
+		for prev.CloseTime.Before(ohlc.OpenTime) {
+			prev = flatCandle(prev, ohlc.Interval, calendar)
+			filled = append(filled, prev)
+		}
+		filled = append(filled, ohlc)
+	}
+
+	return filled
+}
+
+// gapFillStorage decorates a Storage so that GetRange and Query return series without holes
+type gapFillStorage struct {
+	Storage
+	calendar Calendar
+}
+
+// NewGapFillStorage wraps storage so ranges read back through GetRange and Query are gap filled,
+// with candles aligned to calendar
+func NewGapFillStorage(storage Storage, calendar Calendar) Storage {
+	return &gapFillStorage{Storage: storage, calendar: calendar}
+}
+
+// GetRange implements Storage.GetRange, filling missing intervals with synthetic candles
+func (s *gapFillStorage) GetRange(symbol Symbol, interval Interval, start, end time.Time) ([]*OHLC, error) {
+	candles, err := s.Storage.GetRange(symbol, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return FillGaps(candles, s.calendar), nil
+}
+
+// Query implements Storage.Query, filling missing intervals inside each page and between it and the
+// previous page, whose last candle the cursor points at, so that the pages of a query join without
+// holes. Filled pages may hold more candles than the limit
+func (s *gapFillStorage) Query(query RangeQuery) (*RangePage, error) {
+	page, err := s.Storage.Query(query)
+	if err != nil || len(page.Candles) == 0 || query.Interval.IsBar() {
+		return page, err
+	}
+	cursor, _, err := query.CursorPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill by ascending open time, between the candles of the previous page and this one
+	candles := slices.Clone(page.Candles)
+	if query.Descending {
+		slices.Reverse(candles)
+	}
+	var before, after *OHLC
+	switch {
+	case cursor.IsZero():
+	case query.Descending:
+		// Only the open time of the following candle bounds the filling
+		after = &OHLC{Interval: query.Interval, OpenTime: cursor}
+	default:
+		prev, err := s.Storage.Query(RangeQuery{Symbol: query.Symbol, Interval: query.Interval, End: s.calendar.Next(query.Interval, cursor), Limit: 1, Descending: true})
+		if err != nil {
+			return nil, err
+		}
+		if len(prev.Candles) > 0 {
+			before = prev.Candles[0]
+		}
+	}
+
+	if before != nil {
+		candles = append([]*OHLC{before}, candles...)
+	}
+	if after != nil {
+		candles = append(candles, after)
+	}
+	candles = FillGaps(candles, s.calendar)
+	if before != nil {
+		candles = candles[1:]
+	}
+	if after != nil {
+		candles = candles[:len(candles)-1]
+	}
+
+	if query.Descending {
+		slices.Reverse(candles)
+	}
+	return &RangePage{Candles: candles, NextCursor: page.NextCursor}, nil
+}
+
+// Close closes the wrapped storage if it supports closing
+func (s *gapFillStorage) Close() error {
+	if closer, ok := s.Storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
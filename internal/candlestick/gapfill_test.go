@@ -0,0 +1,152 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGapFillLive(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{Intervals: []Interval{Interval1m}, GapFill: true})
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := agg.Process(Tick{Symbol: ETHUSDT, Price: dec("3000"), Quantity: dec("1"), Timestamp: start.Add(5 * time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The timer closes the real candle, then fills the following empty minute
+	if completed := agg.Flush(start.Add(time.Minute)); len(completed) != 1 || completed[0].Synthetic {
+		t.Fatalf("Expected one real candle, got %+v", completed)
+	}
+	completed := agg.Flush(start.Add(2 * time.Minute))
+	if len(completed) != 1 {
+		t.Fatalf("Expected one synthetic candle, got %d", len(completed))
+	}
+	flat := completed[0]
+	if !flat.Synthetic || !flat.Volume.IsZero() || !flat.Open.Equal(dec("3000")) || !flat.High.Equal(dec("3000")) || !flat.Low.Equal(dec("3000")) || !flat.Close.Equal(dec("3000")) {
+		t.Errorf("Unexpected synthetic candle: %+v", flat)
+	}
+	if !flat.OpenTime.Equal(start.Add(time.Minute)) {
+		t.Errorf("Unexpected synthetic open time %s", flat.OpenTime)
+	}
+
+	// A tick after several silent minutes fills the remaining holes before starting its candle
+	completed, err := agg.Process(Tick{Symbol: ETHUSDT, Price: dec("3100"), Quantity: dec("1"), Timestamp: start.Add(4*time.Minute + time.Second)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(completed) != 2 {
+		t.Fatalf("Expected two synthetic candles, got %d", len(completed))
+	}
+	for i, ohlc := range completed {
+		if !ohlc.Synthetic || !ohlc.OpenTime.Equal(start.Add(time.Duration(i+2)*time.Minute)) {
+			t.Errorf("Unexpected candle %d: %+v", i, ohlc)
+		}
+	}
+}
+
+func TestGapFillStorage(t *testing.T) {
+	storage := NewMockStorage()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, 3 * time.Minute} {
+		storage.Store(&OHLC{
+			Symbol:    BTCUSDT,
+			Interval:  Interval1m,
+			Open:      dec("100"),
+			High:      dec("110"),
+			Low:       dec("90"),
+			Close:     dec("105"),
+			Volume:    dec("1"),
+			OpenTime:  start.Add(offset),
+			CloseTime: start.Add(offset + time.Minute),
+		})
+	}
+
+	candles, err := NewGapFillStorage(storage, DefaultCalendar).GetRange(BTCUSDT, Interval1m, start.Add(-time.Minute), start.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(candles) != 4 {
+		t.Fatalf("Expected 4 candles, got %d", len(candles))
+	}
+	for i, ohlc := range candles {
+		if !ohlc.OpenTime.Equal(start.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("Unexpected open time for candle %d: %s", i, ohlc.OpenTime)
+		}
+		if synthetic := i == 1 || i == 2; ohlc.Synthetic != synthetic {
+			t.Errorf("Expected candle %d synthetic=%v", i, synthetic)
+		}
+	}
+	if !candles[1].Open.Equal(dec("105")) || !candles[2].Close.Equal(dec("105")) {
+		t.Errorf("Expected synthetic candles to carry the previous close")
+	}
+}
+
+func TestGapFillStorageQuery(t *testing.T) {
+	storage := NewMockStorage()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, minute := range []int{0, 1, 4, 5, 8} {
+		openTime := start.Add(time.Duration(minute) * time.Minute)
+		price := dec("100").Add(decimal.NewFromInt(int64(minute)))
+		storage.Store(&OHLC{
+			Symbol:    BTCUSDT,
+			Interval:  Interval1m,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Volume:    dec("1"),
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(time.Minute),
+		})
+	}
+
+	tests := []struct {
+		name       string
+		descending bool
+		pages      [][]int
+	}{
+		{"ascending", false, [][]int{{0, 1}, {2, 3, 4, 5}, {6, 7, 8}}},
+		{"descending", true, [][]int{{8, 7, 6, 5}, {4, 3, 2, 1}, {0}}},
+	}
+
+	gapFill := NewGapFillStorage(storage, DefaultCalendar)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The pages hold two stored candles each, plus the ones filled up to the previous page
+			query := RangeQuery{Symbol: BTCUSDT, Interval: Interval1m, Limit: 2, Descending: tt.descending}
+			for i, minutes := range tt.pages {
+				page, err := gapFill.Query(query)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				if len(page.Candles) != len(minutes) {
+					t.Fatalf("Page %d: expected %d candles, got %d", i, len(minutes), len(page.Candles))
+				}
+				for j, ohlc := range page.Candles {
+					if !ohlc.OpenTime.Equal(start.Add(time.Duration(minutes[j]) * time.Minute)) {
+						t.Errorf("Page %d candle %d: unexpected open time %s", i, j, ohlc.OpenTime)
+					}
+				}
+				query.Cursor = page.NextCursor
+			}
+			if query.Cursor != "" {
+				t.Errorf("Expected no cursor after the last page, got %q", query.Cursor)
+			}
+		})
+	}
+
+	// Filled candles carry the close of the stored candle before them, across pages too
+	page, err := gapFill.Query(RangeQuery{Symbol: BTCUSDT, Interval: Interval1m, Start: start.Add(2 * time.Minute), Limit: 2})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	page, err = gapFill.Query(RangeQuery{Symbol: BTCUSDT, Interval: Interval1m, Start: start.Add(2 * time.Minute), Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(page.Candles) != 3 || !page.Candles[0].Synthetic || !page.Candles[0].Close.Equal(dec("105")) {
+		t.Errorf("Expected candles filled with close 105 before the stored 8m candle, got %+v", page.Candles)
+	}
+}
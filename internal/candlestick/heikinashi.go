@@ -0,0 +1,124 @@
+package candlestick
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	two  = decimal.NewFromInt(2)
+	four = decimal.NewFromInt(4)
+)
+
+// HeikinAshi derives Heikin-Ashi candles from closed time candles, one series per symbol and
+// source interval identified by HeikinAshiInterval
+type HeikinAshi struct {
+	mu        sync.Mutex
+	intervals map[Interval]bool
+	last      map[candleKey]*OHLC // latest Heikin-Ashi candle of each series
+	prev      map[candleKey]*OHLC // Heikin-Ashi candle before the latest, which revisions of it derive from
+}
+
+// NewHeikinAshi creates a Heikin-Ashi transform of the candles of each interval
+func NewHeikinAshi(intervals []Interval) *HeikinAshi {
+	h := &HeikinAshi{
+		intervals: make(map[Interval]bool),
+		last:      make(map[candleKey]*OHLC),
+		prev:      make(map[candleKey]*OHLC),
+	}
+	for _, interval := range intervals {
+		h.intervals[interval] = true
+	}
+	return h
+}
+
+// Add transforms a closed candle into the next Heikin-Ashi candle of its series, and a revision of
+// the latest candle into a revision of its Heikin-Ashi candle. It returns nil for candles of other
+// intervals and for candles older than the latest, since every later Heikin-Ashi candle derives
+// its open from them and was already published
+func (h *HeikinAshi) Add(ohlc *OHLC) *OHLC {
+	if !h.intervals[ohlc.Interval] || !ohlc.IsClosed {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := candleKey{symbol: ohlc.Symbol, interval: ohlc.Interval}
+	prev, ok := h.last[key]
+	switch {
+	case ok && ohlc.Revision > 0 && ohlc.OpenTime.Equal(prev.OpenTime):
+		prev, ok = h.prev[key]
+	case ok && !ohlc.OpenTime.After(prev.OpenTime), ohlc.Revision > 0:
+		log.Printf("Skipping Heikin-Ashi of out of order %s candle for symbol=%s at time=%s (revision %d)", ohlc.Interval, ohlc.Symbol, ohlc.OpenTime.Format(time.RFC3339), ohlc.Revision)
+		return nil
+	case ok:
+		h.prev[key] = prev
+	}
+
+	ha := transform(ohlc, prev)
+	h.last[key] = ha
+
+	copy := *ha
+	return &copy
+}
+
+// Current returns the in-progress Heikin-Ashi candle of source, the in-progress candle of its
+// series' interval, derived from the latest Heikin-Ashi candle of the series. It returns nil for
+// candles of other intervals and for candles not newer than the latest
+func (h *HeikinAshi) Current(source *OHLC) *OHLC {
+	if source == nil || !h.intervals[source.Interval] {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.last[candleKey{symbol: source.Symbol, interval: source.Interval}]
+	if prev != nil && !source.OpenTime.After(prev.OpenTime) {
+		return nil
+	}
+	return transform(source, prev)
+}
+
+// Source returns the interval of the candles a Heikin-Ashi series is derived from, if interval
+// identifies one of the series
+func (h *HeikinAshi) Source(interval Interval) (Interval, bool) {
+	source, ok := strings.CutPrefix(string(interval), string(BarTypeHeikinAshi)+":")
+	if !ok || !h.intervals[Interval(source)] {
+		return "", false
+	}
+	return Interval(source), true
+}
+
+// transform returns the Heikin-Ashi candle of ohlc following prev, the Heikin-Ashi candle before
+// it, or starting its series if prev is nil
+func transform(ohlc, prev *OHLC) *OHLC {
+	ha := *ohlc
+	ha.Interval = HeikinAshiInterval(ohlc.Interval)
+	ha.BarType = BarTypeHeikinAshi
+	ha.Close = ohlc.Open.Add(ohlc.High).Add(ohlc.Low).Add(ohlc.Close).Div(four)
+	if prev != nil {
+		ha.Open = prev.Open.Add(prev.Close).Div(two)
+	} else {
+		ha.Open = ohlc.Open.Add(ohlc.Close).Div(two)
+	}
+	ha.High = decimal.Max(ohlc.High, ha.Open, ha.Close)
+	ha.Low = decimal.Min(ohlc.Low, ha.Open, ha.Close)
+	return &ha
+}
+
+// Warm seeds the series from stored closed candles sorted by open time, so that the Heikin-Ashi
+// candles following a restart match those of an uninterrupted run
+func (h *HeikinAshi) Warm(candles []*OHLC) {
+	for _, ohlc := range candles {
+		ohlc := *ohlc
+		ohlc.IsClosed = true
+		ohlc.Revision = 0
+		h.Add(&ohlc)
+	}
+}
@@ -0,0 +1,102 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeikinAshiAdd(t *testing.T) {
+	h := NewHeikinAshi([]Interval{Interval1h})
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(hour int, open, high, low, close string) *OHLC {
+		return &OHLC{Symbol: BTCUSDT, Interval: Interval1h, Open: dec(open), High: dec(high), Low: dec(low), Close: dec(close), OpenTime: start.Add(time.Duration(hour) * time.Hour), IsClosed: true}
+	}
+
+	// The first open is the midpoint of the candle's own open and close
+	ha := h.Add(candle(0, "100", "110", "90", "104"))
+	if ha == nil || ha.Interval != "heikin_ashi:1h" || ha.BarType != BarTypeHeikinAshi {
+		t.Fatalf("Unexpected Heikin-Ashi candle: %+v", ha)
+	}
+	if !ha.Open.Equal(dec("102")) || !ha.Close.Equal(dec("101")) || !ha.High.Equal(dec("110")) || !ha.Low.Equal(dec("90")) {
+		t.Errorf("Unexpected first Heikin-Ashi candle: open=%s, high=%s, low=%s, close=%s", ha.Open, ha.High, ha.Low, ha.Close)
+	}
+
+	// Later opens are the midpoint of the previous Heikin-Ashi candle
+	ha = h.Add(candle(1, "104", "108", "103", "106"))
+	if !ha.Open.Equal(dec("101.5")) || !ha.Close.Equal(dec("105.25")) || !ha.High.Equal(dec("108")) || !ha.Low.Equal(dec("101.5")) {
+		t.Errorf("Unexpected second Heikin-Ashi candle: open=%s, high=%s, low=%s, close=%s", ha.Open, ha.High, ha.Low, ha.Close)
+	}
+
+	// A revision of the latest candle revises its Heikin-Ashi candle from the same previous one
+	revised := candle(1, "104", "109", "103", "106")
+	revised.Revision = 1
+	ha = h.Add(revised)
+	if ha == nil || ha.Revision != 1 || !ha.Open.Equal(dec("101.5")) || !ha.Close.Equal(dec("105.5")) || !ha.High.Equal(dec("109")) {
+		t.Fatalf("Unexpected revised Heikin-Ashi candle: %+v", ha)
+	}
+
+	// The next candle derives from the revision
+	ha = h.Add(candle(2, "106", "107", "105", "106"))
+	if !ha.Open.Equal(dec("103.5")) {
+		t.Errorf("Expected open 103.5 after the revision, got %s", ha.Open)
+	}
+
+	// Revisions of older candles, in-progress candles and other intervals are skipped
+	older := candle(1, "104", "110", "103", "106")
+	older.Revision = 2
+	late := candle(0, "100", "110", "90", "104")
+	inProgress := candle(3, "106", "107", "105", "106")
+	inProgress.IsClosed = false
+	other := candle(3, "106", "107", "105", "106")
+	other.Interval = Interval1m
+	for _, ohlc := range []*OHLC{older, late, inProgress, other} {
+		if ha := h.Add(ohlc); ha != nil {
+			t.Errorf("Expected no Heikin-Ashi candle for %+v, got %+v", ohlc, ha)
+		}
+	}
+}
+
+func TestHeikinAshiCurrent(t *testing.T) {
+	h := NewHeikinAshi([]Interval{Interval1h})
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(hour int, open, high, low, close string) *OHLC {
+		return &OHLC{Symbol: BTCUSDT, Interval: Interval1h, Open: dec(open), High: dec(high), Low: dec(low), Close: dec(close), OpenTime: start.Add(time.Duration(hour) * time.Hour)}
+	}
+
+	// Only the series' own intervals are derived
+	if source, ok := h.Source("heikin_ashi:1h"); !ok || source != Interval1h {
+		t.Errorf("Expected the 1h source interval, got %s", source)
+	}
+	if _, ok := h.Source("heikin_ashi:5m"); ok {
+		t.Error("Expected no source for an unconfigured series")
+	}
+
+	// Before any closed candle, the in-progress candle starts the series
+	ha := h.Current(candle(0, "100", "110", "90", "104"))
+	if ha == nil || ha.Interval != "heikin_ashi:1h" || !ha.Open.Equal(dec("102")) || !ha.Close.Equal(dec("101")) {
+		t.Fatalf("Unexpected in-progress Heikin-Ashi candle: %+v", ha)
+	}
+
+	// After it, the in-progress candle opens from the latest Heikin-Ashi candle, which it leaves alone
+	closed := candle(0, "100", "110", "90", "104")
+	closed.IsClosed = true
+	h.Add(closed)
+	for range 2 {
+		ha = h.Current(candle(1, "104", "108", "103", "106"))
+		if ha == nil || !ha.Open.Equal(dec("101.5")) || !ha.Close.Equal(dec("105.25")) || ha.IsClosed {
+			t.Errorf("Unexpected in-progress Heikin-Ashi candle: %+v", ha)
+		}
+	}
+
+	// Candles not newer than the latest, and of other intervals, have none
+	if ha := h.Current(candle(0, "100", "110", "90", "104")); ha != nil {
+		t.Errorf("Expected no in-progress candle for a closed candle, got %+v", ha)
+	}
+	other := candle(1, "104", "108", "103", "106")
+	other.Interval = Interval5m
+	if ha := h.Current(other); ha != nil {
+		t.Errorf("Expected no in-progress candle of another interval, got %+v", ha)
+	}
+}
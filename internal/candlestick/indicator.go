@@ -0,0 +1,458 @@
+package candlestick
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Indicator incrementally computes technical indicator values from a series of candles
+type Indicator interface {
+	// Update adds a closed candle and returns the indicator values after it, keyed by name
+	Update(ohlc *OHLC) map[string]float64
+	// Peek returns the values the indicator would have if the in-progress candle closed as is,
+	// without adding it
+	Peek(ohlc *OHLC) map[string]float64
+}
+
+// IndicatorSpec describes a configured indicator, identified by "type:parameters", e.g. "sma:20",
+// "ema:50", "rsi:14", "macd:12,26,9", "bb:20,2" or "atr:14"
+type IndicatorSpec struct {
+	Type   string
+	Params []float64
+}
+
+// indicatorParams holds the number of parameters and their defaults per indicator type
+var indicatorParams = map[string][]float64{
+	"sma":  {20},
+	"ema":  {20},
+	"rsi":  {14},
+	"macd": {12, 26, 9},
+	"bb":   {20, 2},
+	"atr":  {14},
+}
+
+// ParseIndicatorSpec parses an indicator identifier, with omitted parameters taking their
+// defaults, e.g. "rsi" is "rsi:14"
+func ParseIndicatorSpec(s string) (IndicatorSpec, error) {
+	indicatorType, params, _ := strings.Cut(s, ":")
+	defaults, ok := indicatorParams[indicatorType]
+	if !ok {
+		return IndicatorSpec{}, fmt.Errorf("unsupported indicator %q", indicatorType)
+	}
+
+	spec := IndicatorSpec{Type: indicatorType, Params: append([]float64(nil), defaults...)}
+	if params == "" {
+		return spec, nil
+	}
+
+	values := strings.Split(params, ",")
+	if len(values) > len(defaults) {
+		return IndicatorSpec{}, fmt.Errorf("indicator %q takes at most %d parameters", indicatorType, len(defaults))
+	}
+	for i, value := range values {
+		param, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || param <= 0 {
+			return IndicatorSpec{}, fmt.Errorf("invalid parameter %q of indicator %q", value, indicatorType)
+		}
+		// Every parameter but the Bollinger Bands width is a number of candles
+		if !(indicatorType == "bb" && i == 1) && param != math.Trunc(param) {
+			return IndicatorSpec{}, fmt.Errorf("period %q of indicator %q must be an integer", value, indicatorType)
+		}
+		spec.Params[i] = param
+	}
+	if spec.Type == "macd" && spec.Params[0] >= spec.Params[1] {
+		return IndicatorSpec{}, fmt.Errorf("fast period of indicator %q must be shorter than its slow period", s)
+	}
+
+	return spec, nil
+}
+
+// String returns the canonical indicator identifier, which prefixes the names of its values
+func (s IndicatorSpec) String() string {
+	params := make([]string, len(s.Params))
+	for i, param := range s.Params {
+		params[i] = strconv.FormatFloat(param, 'f', -1, 64)
+	}
+	return s.Type + ":" + strings.Join(params, ",")
+}
+
+// period returns the i-th parameter as a number of candles
+func (s IndicatorSpec) period(i int) int {
+	return int(s.Params[i])
+}
+
+// New creates the indicator with empty state
+func (s IndicatorSpec) New() Indicator {
+	name := s.String()
+	switch s.Type {
+	case "sma":
+		return &sma{name: name, period: s.period(0)}
+	case "ema":
+		return &emaIndicator{name: name, ema: newEMA(s.period(0))}
+	case "rsi":
+		return &rsi{name: name, gain: newRMA(s.period(0)), loss: newRMA(s.period(0))}
+	case "macd":
+		return &macd{name: name, fast: newEMA(s.period(0)), slow: newEMA(s.period(1)), signal: newEMA(s.period(2))}
+	case "bb":
+		return &bollinger{name: name, period: s.period(0), width: s.Params[1]}
+	case "atr":
+		return &atr{name: name, tr: newRMA(s.period(0))}
+	}
+	return nil
+}
+
+// IndicatorEngine keeps the configured indicators up to date for every series of candles and
+// attaches their values to the candles
+type IndicatorEngine struct {
+	mu         sync.Mutex
+	specs      []IndicatorSpec
+	indicators map[candleKey][]Indicator
+}
+
+// NewIndicatorEngine creates an engine computing the indicators for each symbol and interval
+func NewIndicatorEngine(specs []IndicatorSpec) *IndicatorEngine {
+	return &IndicatorEngine{
+		specs:      specs,
+		indicators: make(map[candleKey][]Indicator),
+	}
+}
+
+// Apply returns a copy of the candle with the indicator values attached. Closed candles advance
+// the indicators of their series, while in-progress candles only peek at them. Revisions are
+// returned unchanged, without indicator values: the indicators already advanced with the original
+// candle and keep no state to rewind it, so they only pick the revision up once rebuilt from
+// storage on restart
+func (e *IndicatorEngine) Apply(ohlc *OHLC) *OHLC {
+	if len(e.specs) == 0 {
+		return ohlc
+	}
+	// Stored revisions already replaced the candle in history, live ones arrive too late
+	if ohlc.IsClosed && ohlc.Revision > 0 {
+		log.Printf("Skipping indicators of revised %s candle for symbol=%s at time=%s", ohlc.Interval, ohlc.Symbol, ohlc.OpenTime.Format(time.RFC3339))
+		return ohlc
+	}
+	return e.apply(ohlc)
+}
+
+// apply attaches the indicator values to a copy of the candle, advancing them on closed candles
+func (e *IndicatorEngine) apply(ohlc *OHLC) *OHLC {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := candleKey{symbol: ohlc.Symbol, interval: ohlc.Interval}
+	indicators, ok := e.indicators[key]
+	if !ok {
+		indicators = make([]Indicator, len(e.specs))
+		for i, spec := range e.specs {
+			indicators[i] = spec.New()
+		}
+		e.indicators[key] = indicators
+	}
+
+	values := make(map[string]float64)
+	for _, indicator := range indicators {
+		var updated map[string]float64
+		if ohlc.IsClosed {
+			updated = indicator.Update(ohlc)
+		} else {
+			updated = indicator.Peek(ohlc)
+		}
+		for name, value := range updated {
+			values[name] = value
+		}
+	}
+
+	copy := *ohlc
+	copy.Indicators = values
+	return &copy
+}
+
+// Warm feeds stored closed candles of one series, sorted by open time, into its indicators and
+// returns them with their indicator values, so that history can be recomputed and the values
+// following a restart match those of an uninterrupted run
+func (e *IndicatorEngine) Warm(candles []*OHLC) []*OHLC {
+	warmed := make([]*OHLC, 0, len(candles))
+	for _, ohlc := range candles {
+		ohlc := *ohlc
+		ohlc.IsClosed = true
+		warmed = append(warmed, e.apply(&ohlc))
+	}
+	return warmed
+}
+
+// Warmup returns the number of closed candles after which every configured indicator has a value
+// that no longer depends noticeably on the candles before them
+func (e *IndicatorEngine) Warmup() int {
+	warmup := 0
+	for _, spec := range e.specs {
+		periods := 0
+		for i := range spec.Params {
+			if spec.Type != "bb" || i == 0 {
+				periods += spec.period(i)
+			}
+		}
+		// Exponential averages need a few periods to forget their seed
+		warmup = max(warmup, 4*periods)
+	}
+	return warmup
+}
+
+// ema is an exponential moving average seeded with the simple average of its first period values
+type ema struct {
+	period int
+	alpha  float64
+	count  int
+	sum    float64
+	value  float64
+}
+
+// newEMA creates an exponential moving average weighting new values by 2/(period+1)
+func newEMA(period int) *ema {
+	return &ema{period: period, alpha: 2 / float64(period+1)}
+}
+
+// newRMA creates Wilder's moving average weighting new values by 1/period, as used by RSI and ATR
+func newRMA(period int) *ema {
+	return &ema{period: period, alpha: 1 / float64(period)}
+}
+
+// next returns the average after x without adding it, and whether it has a full period of values
+func (e *ema) next(x float64) (float64, bool) {
+	switch {
+	case e.count+1 < e.period:
+		return 0, false
+	case e.count+1 == e.period:
+		return (e.sum + x) / float64(e.period), true
+	}
+	return e.value + e.alpha*(x-e.value), true
+}
+
+// add adds x to the average and returns it, and whether it has a full period of values
+func (e *ema) add(x float64) (float64, bool) {
+	value, ok := e.next(x)
+	e.count++
+	e.sum += x
+	e.value = value
+	return value, ok
+}
+
+// sma is the simple moving average of the close
+type sma struct {
+	name   string
+	period int
+	closes []float64 // the last period closes
+}
+
+func (s *sma) Update(ohlc *OHLC) map[string]float64 {
+	values := s.Peek(ohlc)
+	s.closes = appendWindow(s.closes, ohlc.Close.InexactFloat64(), s.period)
+	return values
+}
+
+func (s *sma) Peek(ohlc *OHLC) map[string]float64 {
+	window, ok := peekWindow(s.closes, ohlc.Close.InexactFloat64(), s.period)
+	if !ok {
+		return nil
+	}
+	mean, _ := meanStdDev(window)
+	return map[string]float64{s.name: mean}
+}
+
+// emaIndicator is the exponential moving average of the close
+type emaIndicator struct {
+	name string
+	ema  *ema
+}
+
+func (e *emaIndicator) Update(ohlc *OHLC) map[string]float64 {
+	value, ok := e.ema.add(ohlc.Close.InexactFloat64())
+	return single(e.name, value, ok)
+}
+
+func (e *emaIndicator) Peek(ohlc *OHLC) map[string]float64 {
+	value, ok := e.ema.next(ohlc.Close.InexactFloat64())
+	return single(e.name, value, ok)
+}
+
+// rsi is Wilder's relative strength index of the close
+type rsi struct {
+	name       string
+	gain, loss *ema
+	prevClose  float64
+	started    bool
+}
+
+func (r *rsi) Update(ohlc *OHLC) map[string]float64 {
+	close := ohlc.Close.InexactFloat64()
+	defer func() { r.prevClose, r.started = close, true }()
+	if !r.started {
+		return nil
+	}
+
+	change := close - r.prevClose
+	gain, ok := r.gain.add(math.Max(change, 0))
+	loss, _ := r.loss.add(math.Max(-change, 0))
+	return single(r.name, relativeStrength(gain, loss), ok)
+}
+
+func (r *rsi) Peek(ohlc *OHLC) map[string]float64 {
+	if !r.started {
+		return nil
+	}
+
+	change := ohlc.Close.InexactFloat64() - r.prevClose
+	gain, ok := r.gain.next(math.Max(change, 0))
+	loss, _ := r.loss.next(math.Max(-change, 0))
+	return single(r.name, relativeStrength(gain, loss), ok)
+}
+
+// relativeStrength converts average gains and losses into an index between 0 and 100
+func relativeStrength(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// macd is the moving average convergence divergence of the close, with its signal line and
+// histogram
+type macd struct {
+	name               string
+	fast, slow, signal *ema
+}
+
+func (m *macd) Update(ohlc *OHLC) map[string]float64 {
+	close := ohlc.Close.InexactFloat64()
+	fast, _ := m.fast.add(close)
+	slow, ok := m.slow.add(close)
+	if !ok {
+		return nil
+	}
+	signal, signalOK := m.signal.add(fast - slow)
+	return m.values(fast-slow, signal, signalOK)
+}
+
+func (m *macd) Peek(ohlc *OHLC) map[string]float64 {
+	close := ohlc.Close.InexactFloat64()
+	fast, _ := m.fast.next(close)
+	slow, ok := m.slow.next(close)
+	if !ok {
+		return nil
+	}
+	signal, signalOK := m.signal.next(fast - slow)
+	return m.values(fast-slow, signal, signalOK)
+}
+
+// values names the MACD line and, once available, the signal line and histogram
+func (m *macd) values(line, signal float64, signalOK bool) map[string]float64 {
+	values := map[string]float64{m.name: line}
+	if signalOK {
+		values[m.name+":signal"] = signal
+		values[m.name+":histogram"] = line - signal
+	}
+	return values
+}
+
+// bollinger are the Bollinger Bands of the close, width standard deviations around its simple
+// moving average
+type bollinger struct {
+	name   string
+	period int
+	width  float64
+	closes []float64
+}
+
+func (b *bollinger) Update(ohlc *OHLC) map[string]float64 {
+	values := b.Peek(ohlc)
+	b.closes = appendWindow(b.closes, ohlc.Close.InexactFloat64(), b.period)
+	return values
+}
+
+func (b *bollinger) Peek(ohlc *OHLC) map[string]float64 {
+	window, ok := peekWindow(b.closes, ohlc.Close.InexactFloat64(), b.period)
+	if !ok {
+		return nil
+	}
+	mean, stdDev := meanStdDev(window)
+	return map[string]float64{
+		b.name + ":middle": mean,
+		b.name + ":upper":  mean + b.width*stdDev,
+		b.name + ":lower":  mean - b.width*stdDev,
+	}
+}
+
+// atr is Wilder's average true range
+type atr struct {
+	name      string
+	tr        *ema
+	prevClose float64
+	started   bool
+}
+
+func (a *atr) Update(ohlc *OHLC) map[string]float64 {
+	value, ok := a.tr.add(a.trueRange(ohlc))
+	a.prevClose, a.started = ohlc.Close.InexactFloat64(), true
+	return single(a.name, value, ok)
+}
+
+func (a *atr) Peek(ohlc *OHLC) map[string]float64 {
+	value, ok := a.tr.next(a.trueRange(ohlc))
+	return single(a.name, value, ok)
+}
+
+// trueRange returns the candle's range extended to the previous close
+func (a *atr) trueRange(ohlc *OHLC) float64 {
+	high, low := ohlc.High.InexactFloat64(), ohlc.Low.InexactFloat64()
+	if !a.started {
+		return high - low
+	}
+	return math.Max(high-low, math.Max(math.Abs(high-a.prevClose), math.Abs(low-a.prevClose)))
+}
+
+// single names a single indicator value, or returns nil while it is not available
+func single(name string, value float64, ok bool) map[string]float64 {
+	if !ok {
+		return nil
+	}
+	return map[string]float64{name: value}
+}
+
+// appendWindow appends x to a window of at most size values
+func appendWindow(window []float64, x float64, size int) []float64 {
+	window = append(window, x)
+	if len(window) > size {
+		window = window[len(window)-size:]
+	}
+	return window
+}
+
+// peekWindow returns the full window of size values that would end in x, if there is one
+func peekWindow(window []float64, x float64, size int) ([]float64, bool) {
+	if len(window)+1 < size {
+		return nil, false
+	}
+	return append(window[len(window)+1-size:len(window):len(window)], x), true
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
@@ -0,0 +1,107 @@
+package candlestick
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseIndicatorSpec(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "sma:50", expected: "sma:50"},
+		{input: "rsi", expected: "rsi:14"},
+		{input: "macd:5,35", expected: "macd:5,35,9"},
+		{input: "bb:20,2.5", expected: "bb:20,2.5"},
+		{input: "ema:1.5", wantErr: true},
+		{input: "atr:0", wantErr: true},
+		{input: "macd:26,12", wantErr: true},
+		{input: "sma:10,20", wantErr: true},
+		{input: "vwma:20", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			spec, err := ParseIndicatorSpec(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && spec.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, spec.String())
+			}
+		})
+	}
+}
+
+func TestIndicatorEngineApply(t *testing.T) {
+	var specs []IndicatorSpec
+	for _, s := range []string{"sma:2", "ema:3", "rsi:2", "macd:2,3,2", "bb:2,1", "atr:2"} {
+		spec, err := ParseIndicatorSpec(s)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		specs = append(specs, spec)
+	}
+	engine := NewIndicatorEngine(specs)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []*OHLC
+	for i, price := range []int64{10, 12, 11, 13} {
+		close := decimal.NewFromInt(price)
+		candles = append(candles, &OHLC{Symbol: BTCUSDT, Interval: Interval1h, Open: close, High: close.Add(dec("1")), Low: close.Sub(dec("1")), Close: close, OpenTime: start.Add(time.Duration(i) * time.Hour), IsClosed: true})
+	}
+
+	var applied []*OHLC
+	for _, ohlc := range candles {
+		applied = append(applied, engine.Apply(ohlc))
+	}
+
+	// Values only appear once an indicator has a full period of candles
+	if _, ok := applied[0].Indicators["sma:2"]; ok {
+		t.Errorf("Expected no SMA for the first candle, got %v", applied[0].Indicators)
+	}
+	expected := map[string]float64{
+		"sma:2":                12,
+		"ema:3":                12,
+		"rsi:2":                100 - 100/7.0,
+		"macd:2,3,2":           1.0 / 3,
+		"macd:2,3,2:signal":    1.0 / 6,
+		"macd:2,3,2:histogram": 1.0 / 6,
+		"bb:2,1:middle":        12,
+		"bb:2,1:upper":         13,
+		"bb:2,1:lower":         11,
+		"atr:2":                2.625,
+	}
+	last := applied[len(applied)-1].Indicators
+	for name, value := range expected {
+		if math.Abs(last[name]-value) > 1e-9 {
+			t.Errorf("Expected %s of %v, got %v", name, value, last[name])
+		}
+	}
+	if candles[3].Indicators != nil {
+		t.Error("Expected the candle passed in to be left unchanged")
+	}
+
+	// Peeking at an in-progress candle leaves the indicators where they are
+	inProgress := &OHLC{Symbol: BTCUSDT, Interval: Interval1h, Open: dec("13"), High: dec("20"), Low: dec("13"), Close: dec("19"), IsClosed: false}
+	peeked := engine.Apply(inProgress)
+	if got := peeked.Indicators["sma:2"]; got != 16 {
+		t.Errorf("Expected peeked SMA of 16, got %v", got)
+	}
+	if got := engine.Apply(inProgress).Indicators["sma:2"]; got != 16 {
+		t.Errorf("Expected peeking to be repeatable, got SMA of %v", got)
+	}
+
+	// Warming up from stored candles yields the values of the live series
+	warmed := NewIndicatorEngine(specs).Warm(candles)
+	for name, value := range last {
+		if warmed[3].Indicators[name] != value {
+			t.Errorf("Expected warmed %s of %v, got %v", name, value, warmed[3].Indicators[name])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package candlestick
+
+import (
+	"fmt"
+	"time"
+)
+
+// Interval represents a candlestick time frame, using Binance's notation (e.g. "1m", "4h")
+type Interval string
+
+const (
+	Interval1s  Interval = "1s"
+	Interval1m  Interval = "1m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval1h  Interval = "1h"
+	Interval4h  Interval = "4h"
+	Interval1d  Interval = "1d"
+	Interval1w  Interval = "1w"
+	Interval1M  Interval = "1M"
+)
+
+// intervalDurations maps the supported intervals to their length; weeks and months are nominal,
+// their candles follow the Calendar
+var intervalDurations = map[Interval]time.Duration{
+	Interval1s:  time.Second,
+	Interval1m:  time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+	Interval1M:  30 * 24 * time.Hour,
+}
+
+// ParseInterval validates and converts a string such as "5m" into an Interval
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(s)
+	if _, ok := intervalDurations[interval]; !ok {
+		return "", fmt.Errorf("unsupported interval %q", s)
+	}
+	return interval, nil
+}
+
+// Duration returns the length of the interval, nominal for calendar intervals such as months
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
+
+// String implements fmt.Stringer
+func (i Interval) String() string {
+	return string(i)
+}
@@ -0,0 +1,88 @@
+package candlestick
+
+import (
+	"sync"
+	"time"
+)
+
+// MockClock implements the Clock interface for testing, only moving forward when advanced
+type MockClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*mockWaiter
+	changed chan struct{}
+}
+
+// mockWaiter is a pending After call
+type mockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewMockClock creates a new mock clock set to now
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+// Now implements Clock.Now
+func (c *MockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements Clock.After
+func (c *MockClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &mockWaiter{deadline: c.now.Add(d), ch: ch})
+	c.notify()
+	return ch
+}
+
+// Advance moves the clock forward and fires every waiter whose deadline has passed
+func (c *MockClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+	c.notify()
+}
+
+// BlockUntil waits until at least n goroutines are blocked on After (helper for testing)
+func (c *MockClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		waiting, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+
+		if waiting >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// notify wakes up BlockUntil callers; must be called with the lock held
+func (c *MockClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
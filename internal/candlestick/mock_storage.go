@@ -1,15 +1,20 @@
 package candlestick
 
 import (
+	"cmp"
+	"sort"
 	"sync"
 	"time"
 )
 
 // mockStorage implements the Storage interface for testing
 type mockStorage struct {
-	mu    sync.RWMutex
-	ticks []*Tick
-	ohlcs []*OHLC
+	mu       sync.RWMutex
+	ticks    []*Tick
+	ohlcs    []*OHLC
+	snapshot []*OHLC
+
+	footprints []*Footprint
 }
 
 // NewMockStorage creates a new mock storage for testing
@@ -24,6 +29,13 @@ func NewMockStorage() Storage {
 func (m *mockStorage) Store(ohlc *OHLC) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	for i, stored := range m.ohlcs {
+		if stored.Symbol == ohlc.Symbol && stored.Interval == ohlc.Interval && stored.OpenTime.Equal(ohlc.OpenTime) &&
+			(!ohlc.BarType.IsBar() || stored.FirstTradeID == ohlc.FirstTradeID) {
+			m.ohlcs[i] = ohlc
+			return nil
+		}
+	}
 	m.ohlcs = append(m.ohlcs, ohlc)
 	return nil
 }
@@ -36,17 +48,226 @@ func (m *mockStorage) StoreTick(tick *Tick) error {
 	return nil
 }
 
+// StoreTicks implements Storage.StoreTicks
+func (m *mockStorage) StoreTicks(ticks []*Tick) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ticks = append(m.ticks, ticks...)
+	return nil
+}
+
 // GetRange implements Storage.GetRange
-func (m *mockStorage) GetRange(symbol Symbol, start, end time.Time) ([]*OHLC, error) {
+func (m *mockStorage) GetRange(symbol Symbol, interval Interval, start, end time.Time) ([]*OHLC, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*OHLC
+	for _, ohlc := range m.ohlcs {
+		if ohlc.Symbol == symbol && ohlc.Interval == interval && !ohlc.OpenTime.Before(start) && ohlc.OpenTime.Before(end) {
+			result = append(result, ohlc)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].OpenTime.Equal(result[j].OpenTime) {
+			return result[i].OpenTime.Before(result[j].OpenTime)
+		}
+		return result[i].FirstTradeID < result[j].FirstTradeID
+	})
+	return result, nil
+}
+
+// Query implements Storage.Query
+func (m *mockStorage) Query(query RangeQuery) (*RangePage, error) {
+	cursor, cursorTradeID, err := query.CursorPosition()
+	if err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	var result []*OHLC
 	for _, ohlc := range m.ohlcs {
-		if ohlc.Symbol == symbol && ohlc.OpenTime.After(start) && ohlc.CloseTime.Before(end) {
+		switch {
+		case ohlc.Symbol != query.Symbol || ohlc.Interval != query.Interval:
+		case !query.Start.IsZero() && ohlc.OpenTime.Before(query.Start):
+		case !query.End.IsZero() && !ohlc.OpenTime.Before(query.End):
+		case !cursor.IsZero() && !query.Descending && comparePosition(ohlc, cursor, cursorTradeID) <= 0:
+		case !cursor.IsZero() && query.Descending && comparePosition(ohlc, cursor, cursorTradeID) >= 0:
+		default:
 			result = append(result, ohlc)
 		}
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		order := comparePosition(result[i], result[j].OpenTime, result[j].FirstTradeID)
+		if query.Descending {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	if len(result) > query.PageSize()+1 {
+		result = result[:query.PageSize()+1]
+	}
+	return NewRangePage(result, query), nil
+}
+
+// comparePosition orders a candle against an open time and first trade ID
+func comparePosition(ohlc *OHLC, openTime time.Time, firstTradeID int64) int {
+	if order := ohlc.OpenTime.Compare(openTime); order != 0 {
+		return order
+	}
+	return cmp.Compare(ohlc.FirstTradeID, firstTradeID)
+}
+
+// GetTicks implements Storage.GetTicks
+func (m *mockStorage) GetTicks(symbol Symbol, start, end time.Time) ([]*Tick, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*Tick
+	for _, tick := range m.ticks {
+		if tick.Symbol == symbol && !tick.Timestamp.Before(start) && tick.Timestamp.Before(end) {
+			result = append(result, tick)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		}
+		return result[i].TradeID < result[j].TradeID
+	})
+	return result, nil
+}
+
+// OldestTick implements RetentionStorage.OldestTick
+func (m *mockStorage) OldestTick() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var oldest time.Time
+	for _, tick := range m.ticks {
+		if oldest.IsZero() || tick.Timestamp.Before(oldest) {
+			oldest = tick.Timestamp
+		}
+	}
+	return oldest, nil
+}
+
+// GetAllTicks implements RetentionStorage.GetAllTicks
+func (m *mockStorage) GetAllTicks(start, end time.Time) ([]*Tick, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*Tick
+	for _, tick := range m.ticks {
+		if !tick.Timestamp.Before(start) && tick.Timestamp.Before(end) {
+			result = append(result, tick)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		}
+		return result[i].TradeID < result[j].TradeID
+	})
+	return result, nil
+}
+
+// CountTicks implements RetentionStorage.CountTicks
+func (m *mockStorage) CountTicks(before time.Time) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, tick := range m.ticks {
+		if tick.Timestamp.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteTicks implements RetentionStorage.DeleteTicks
+func (m *mockStorage) DeleteTicks(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.ticks[:0]
+	for _, tick := range m.ticks {
+		if !tick.Timestamp.Before(before) {
+			kept = append(kept, tick)
+		}
+	}
+	deleted := int64(len(m.ticks) - len(kept))
+	m.ticks = kept
+	return deleted, nil
+}
+
+// CountCandles implements RetentionStorage.CountCandles
+func (m *mockStorage) CountCandles(interval Interval, before time.Time) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, ohlc := range m.ohlcs {
+		if ohlc.Interval == interval && ohlc.OpenTime.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteCandles implements RetentionStorage.DeleteCandles
+func (m *mockStorage) DeleteCandles(interval Interval, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.ohlcs[:0]
+	for _, ohlc := range m.ohlcs {
+		if ohlc.Interval != interval || !ohlc.OpenTime.Before(before) {
+			kept = append(kept, ohlc)
+		}
+	}
+	deleted := int64(len(m.ohlcs) - len(kept))
+	m.ohlcs = kept
+	return deleted, nil
+}
+
+// SaveSnapshot implements Storage.SaveSnapshot
+func (m *mockStorage) SaveSnapshot(candles []*OHLC) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot = candles
+	return nil
+}
+
+// LoadSnapshot implements Storage.LoadSnapshot
+func (m *mockStorage) LoadSnapshot() ([]*OHLC, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.snapshot, nil
+}
+
+// StoreFootprint implements FootprintStorage.StoreFootprint
+func (m *mockStorage) StoreFootprint(footprint *Footprint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.footprints = append(m.footprints, footprint)
+	return nil
+}
+
+// GetFootprints implements FootprintStorage.GetFootprints
+func (m *mockStorage) GetFootprints(symbol Symbol, interval Interval, start, end time.Time) ([]*Footprint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*Footprint
+	for _, footprint := range m.footprints {
+		if footprint.Symbol == symbol && footprint.Interval == interval && !footprint.OpenTime.Before(start) && footprint.OpenTime.Before(end) {
+			result = append(result, footprint)
+		}
+	}
 	return result, nil
 }
 
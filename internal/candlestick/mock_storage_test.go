@@ -0,0 +1,14 @@
+package candlestick_test
+
+import (
+	"testing"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/azanium/ohlc/internal/storage/storagetest"
+)
+
+func TestMockStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) candlestick.Storage {
+		return candlestick.NewMockStorage()
+	})
+}
@@ -0,0 +1,51 @@
+package candlestick
+
+import "github.com/shopspring/decimal"
+
+// Precision holds the number of decimal places a symbol is quoted with
+type Precision struct {
+	Price    int32 // decimal places of prices
+	Quantity int32 // decimal places of base asset quantities
+}
+
+// DefaultPrecision is used for symbols without a configured precision
+var DefaultPrecision = Precision{Price: 8, Quantity: 8}
+
+// DefaultPrecisions holds the exchange precision of the built-in symbols
+var DefaultPrecisions = Precisions{
+	BTCUSDT:  {Price: 2, Quantity: 5},
+	ETHUSDT:  {Price: 2, Quantity: 4},
+	PEPEUSDT: {Price: 8, Quantity: 0},
+}
+
+// Quote returns the decimal places of quote asset amounts, which are exact products of a
+// price and a quantity
+func (p Precision) Quote() int32 {
+	return p.Price + p.Quantity
+}
+
+// FormatPrice formats a price with the symbol's price precision
+func (p Precision) FormatPrice(d decimal.Decimal) string {
+	return d.StringFixed(p.Price)
+}
+
+// FormatQuantity formats a base asset quantity with the symbol's quantity precision
+func (p Precision) FormatQuantity(d decimal.Decimal) string {
+	return d.StringFixed(p.Quantity)
+}
+
+// FormatQuote formats a quote asset amount with the symbol's quote precision
+func (p Precision) FormatQuote(d decimal.Decimal) string {
+	return d.StringFixed(p.Quote())
+}
+
+// Precisions maps symbols to their precision
+type Precisions map[Symbol]Precision
+
+// For returns the precision of symbol, falling back to DefaultPrecision
+func (p Precisions) For(symbol Symbol) Precision {
+	if precision, ok := p[symbol]; ok {
+		return precision
+	}
+	return DefaultPrecision
+}
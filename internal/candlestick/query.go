@@ -0,0 +1,82 @@
+package candlestick
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRangeLimit is the most candles a RangeQuery returns per page
+const MaxRangeLimit = 1000
+
+// RangeQuery selects a page of the candles of a symbol and interval by open time. Start is
+// inclusive and End exclusive, so consecutive ranges never return a candle twice. Bars opening at
+// the same time are ordered by first trade
+type RangeQuery struct {
+	Symbol     Symbol
+	Interval   Interval
+	Start      time.Time // earliest open time, zero for no lower bound
+	End        time.Time // open times must be before End, zero for no upper bound
+	Limit      int       // most candles per page, zero or above MaxRangeLimit for MaxRangeLimit
+	Descending bool      // newest candles first
+	Cursor     string    // NextCursor of the previous page, empty for the first page
+}
+
+// RangePage is a page of candles returned by a RangeQuery
+type RangePage struct {
+	Candles    []*OHLC
+	NextCursor string // continues after the last candle of the page, empty on the last page
+}
+
+// PageSize returns the number of candles per page, bounded by MaxRangeLimit
+func (q RangeQuery) PageSize() int {
+	if q.Limit <= 0 || q.Limit > MaxRangeLimit {
+		return MaxRangeLimit
+	}
+	return q.Limit
+}
+
+// CursorPosition returns the open time and first trade ID of the last candle of the previous page,
+// or the zero time for the first page. Cursors holding an open time only, issued before bars were
+// ordered by first trade, continue past every candle opening at that time
+func (q RangeQuery) CursorPosition() (time.Time, int64, error) {
+	if q.Cursor == "" {
+		return time.Time{}, 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor %q", q.Cursor)
+	}
+	openTime, tradeID, found := strings.Cut(string(raw), ":")
+	nanos, err := strconv.ParseInt(openTime, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor %q", q.Cursor)
+	}
+
+	firstTradeID := int64(math.MaxInt64)
+	if q.Descending {
+		firstTradeID = math.MinInt64
+	}
+	if found {
+		if firstTradeID, err = strconv.ParseInt(tradeID, 10, 64); err != nil {
+			return time.Time{}, 0, fmt.Errorf("invalid cursor %q", q.Cursor)
+		}
+	}
+	return time.Unix(0, nanos).UTC(), firstTradeID, nil
+}
+
+// NewRangePage builds the page of a query from the candles matching it in query order, of which
+// storages fetch one more than the page size to tell whether another page follows
+func NewRangePage(candles []*OHLC, query RangeQuery) *RangePage {
+	page := &RangePage{Candles: candles}
+	if len(candles) > query.PageSize() {
+		page.Candles = candles[:query.PageSize()]
+		last := page.Candles[len(page.Candles)-1]
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%d", last.OpenTime.UnixNano(), last.FirstTradeID))
+	}
+	return page
+}
@@ -0,0 +1,63 @@
+package candlestick
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRangeQueryPageSize(t *testing.T) {
+	tests := []struct {
+		limit    int
+		expected int
+	}{
+		{0, MaxRangeLimit},
+		{-1, MaxRangeLimit},
+		{10, 10},
+		{MaxRangeLimit, MaxRangeLimit},
+		{MaxRangeLimit + 1, MaxRangeLimit},
+	}
+
+	for _, tt := range tests {
+		if got := (RangeQuery{Limit: tt.limit}).PageSize(); got != tt.expected {
+			t.Errorf("Limit %d: expected page size %d, got %d", tt.limit, tt.expected, got)
+		}
+	}
+}
+
+func TestNewRangePageCursor(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := []*OHLC{{OpenTime: start, FirstTradeID: 1}, {OpenTime: start.Add(time.Minute), FirstTradeID: 2}, {OpenTime: start.Add(2 * time.Minute), FirstTradeID: 3}}
+
+	page := NewRangePage(candles, RangeQuery{Limit: 2})
+	if len(page.Candles) != 2 || page.NextCursor == "" {
+		t.Fatalf("Expected 2 candles and a next cursor, got %d candles and cursor %q", len(page.Candles), page.NextCursor)
+	}
+	cursor, firstTradeID, err := RangeQuery{Cursor: page.NextCursor}.CursorPosition()
+	if err != nil || !cursor.Equal(start.Add(time.Minute)) || firstTradeID != 2 {
+		t.Errorf("Expected cursor at %v and trade 2, got %v and trade %d (%v)", start.Add(time.Minute), cursor, firstTradeID, err)
+	}
+
+	if page := NewRangePage(candles, RangeQuery{Limit: 3}); len(page.Candles) != 3 || page.NextCursor != "" {
+		t.Errorf("Expected a single page of 3 candles, got %d candles and cursor %q", len(page.Candles), page.NextCursor)
+	}
+}
+
+func TestRangeQueryLegacyCursor(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	legacy := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(start.UnixNano(), 10)))
+
+	// Cursors without a first trade continue past every candle opening at their time
+	for _, descending := range []bool{false, true} {
+		cursor, firstTradeID, err := RangeQuery{Cursor: legacy, Descending: descending}.CursorPosition()
+		expected := int64(math.MaxInt64)
+		if descending {
+			expected = math.MinInt64
+		}
+		if err != nil || !cursor.Equal(start) || firstTradeID != expected {
+			t.Errorf("Descending %t: expected cursor at %v and trade %d, got %v and trade %d (%v)", descending, start, expected, cursor, firstTradeID, err)
+		}
+	}
+}
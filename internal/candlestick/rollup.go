@@ -0,0 +1,408 @@
+package candlestick
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rollup builds higher-timeframe candles from closed candles of a lower source interval, so
+// they can be produced or rebuilt without reprocessing raw ticks
+type Rollup struct {
+	mu              sync.Mutex
+	source          Interval
+	targets         []Interval
+	precisions      Precisions
+	calendar        Calendar
+	allowedLateness time.Duration
+	current         map[candleKey]*rolledCandle
+	closed          map[candleKey][]*rolledCandle // completed candles whose sources may still be revised, by open time
+	completedUntil  map[candleKey]time.Time       // close time of the last completed candle
+	watermarks      map[Symbol]time.Time          // latest close time of a source candle
+	updated         map[Symbol]bool               // symbols that took in source candles since the last Updated call
+}
+
+// rolledCandle is a candle being rolled up, or completed but with sources that may still be
+// revised. Sources older than the allowed lateness are folded into base, so that revising one of
+// the recent sources rebuilds the candle from a handful of candles
+type rolledCandle struct {
+	ohlc     *OHLC     // the candle as last built
+	base     *OHLC     // merge of the sources that can no longer be revised, nil while there are none
+	folded   time.Time // close time of the last source merged into base
+	recent   []*OHLC   // sources that may still be revised, by open time
+	revision int       // revision the candle was last emitted with
+}
+
+// NewRollup creates a rollup of source candles into each target interval, aligned to calendar;
+// targets that are not a multiple of source are ignored. Revisions of source candles closing up
+// to allowedLateness before the latest source candle re-emit the candles containing them
+func NewRollup(source Interval, targets []Interval, precisions Precisions, calendar Calendar, allowedLateness time.Duration) *Rollup {
+	var valid []Interval
+	for _, target := range targets {
+		if target.Duration() <= source.Duration() || target.Duration()%source.Duration() != 0 {
+			log.Printf("Ignoring rollup of %s candles into %s", source, target)
+			continue
+		}
+		valid = append(valid, target)
+	}
+
+	return &Rollup{
+		source:          source,
+		targets:         valid,
+		precisions:      precisions,
+		calendar:        calendar,
+		allowedLateness: allowedLateness,
+		current:         make(map[candleKey]*rolledCandle),
+		closed:          make(map[candleKey][]*rolledCandle),
+		completedUntil:  make(map[candleKey]time.Time),
+		watermarks:      make(map[Symbol]time.Time),
+		updated:         make(map[Symbol]bool),
+	}
+}
+
+// Source returns the interval of the candles the rollup consumes
+func (r *Rollup) Source() Interval {
+	return r.source
+}
+
+// Intervals returns the intervals the rollup produces
+func (r *Rollup) Intervals() []Interval {
+	return r.targets
+}
+
+// Add merges a closed source candle into the candles containing it and returns the ones it
+// completed. A revision of a source candle, or a source candle arriving late, rebuilds a
+// completed candle containing it and returns it as its next revision; candles of other intervals
+// and sources of candles completed longer than the allowed lateness ago are ignored
+func (r *Rollup) Add(ohlc *OHLC) []*OHLC {
+	if ohlc.Interval != r.source {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updated[ohlc.Symbol] = true
+	if ohlc.CloseTime.After(r.watermarks[ohlc.Symbol]) {
+		r.watermarks[ohlc.Symbol] = ohlc.CloseTime
+	}
+	precision := r.precisions.For(ohlc.Symbol)
+
+	var completed []*OHLC
+	for _, target := range r.targets {
+		key := candleKey{symbol: ohlc.Symbol, interval: target}
+		startTime := r.calendar.Truncate(target, ohlc.OpenTime)
+
+		if rolled := r.closedAt(key, startTime); rolled != nil {
+			if !rolled.add(ohlc, precision) {
+				continue
+			}
+			rolled.revision++
+			revised := *rolled.ohlc
+			revised.Revision = rolled.revision
+			revised.IsClosed = true
+			log.Printf("Revising rolled up %s candle for symbol=%s at time=%s (revision %d)", target, ohlc.Symbol, startTime.Format(time.RFC3339), revised.Revision)
+			completed = append(completed, &revised)
+			continue
+		}
+
+		current, exists := r.current[key]
+		if (exists && startTime.Before(current.ohlc.OpenTime)) || startTime.Before(r.completedUntil[key]) {
+			log.Printf("Skipping rollup of late %s candle for symbol=%s at time=%s", ohlc.Interval, ohlc.Symbol, ohlc.OpenTime.Format(time.RFC3339))
+			continue
+		}
+		if exists && !startTime.Equal(current.ohlc.OpenTime) {
+			completed = append(completed, r.complete(key, current))
+			exists = false
+		}
+
+		if !exists {
+			current = &rolledCandle{ohlc: &OHLC{
+				Symbol:    ohlc.Symbol,
+				Interval:  target,
+				OpenTime:  startTime,
+				CloseTime: r.calendar.Next(target, startTime),
+				BarType:   BarTypeTime,
+			}}
+			r.current[key] = current
+		}
+		current.add(ohlc, precision)
+
+		// The source candle ending the target candle completes it
+		if !ohlc.CloseTime.Before(current.ohlc.CloseTime) {
+			completed = append(completed, r.complete(key, current))
+		}
+	}
+
+	r.expire(ohlc.Symbol, precision)
+	sortOHLCs(completed)
+	return completed
+}
+
+// Flush completes and returns every rolled up candle whose close time is at or before now
+func (r *Rollup) Flush(now time.Time) []*OHLC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var completed []*OHLC
+	for key, rolled := range r.current {
+		if !rolled.ohlc.CloseTime.After(now) {
+			completed = append(completed, r.complete(key, rolled))
+		}
+	}
+
+	sortOHLCs(completed)
+	return completed
+}
+
+// Current returns a copy of the in-progress candle of a symbol and interval, with source, the
+// in-progress candle of the source interval if not nil, merged into it, or nil if there is none
+func (r *Rollup) Current(symbol Symbol, interval Interval, source *OHLC) *OHLC {
+	if !slices.Contains(r.targets, interval) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := candleKey{symbol: symbol, interval: interval}
+	var ohlc *OHLC
+	rolled, exists := r.current[key]
+	if exists {
+		copy := *rolled.ohlc
+		ohlc = &copy
+	}
+	if source == nil || source.Symbol != symbol || source.Interval != r.source {
+		return ohlc
+	}
+
+	precision := r.precisions.For(symbol)
+	startTime := r.calendar.Truncate(interval, source.OpenTime)
+	switch {
+	case exists && startTime.Equal(ohlc.OpenTime):
+		// The source may have closed and been rolled up since it was read
+		if !rolled.contains(source.OpenTime) {
+			merge(ohlc, source, precision)
+		}
+	case (!exists || startTime.After(ohlc.OpenTime)) && !startTime.Before(r.completedUntil[key]):
+		// The source opens a candle of its own, the rolled up one is about to be completed
+		ohlc = seed(&OHLC{
+			Symbol:    symbol,
+			Interval:  interval,
+			OpenTime:  startTime,
+			CloseTime: r.calendar.Next(interval, startTime),
+			BarType:   BarTypeTime,
+		}, source)
+		merge(ohlc, source, precision)
+	}
+	return ohlc
+}
+
+// Updated returns the symbols whose in-progress candles took in source candles since the previous
+// call, in symbol order
+func (r *Rollup) Updated() []Symbol {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	symbols := make([]Symbol, 0, len(r.updated))
+	for symbol := range r.updated {
+		symbols = append(symbols, symbol)
+	}
+	clear(r.updated)
+	slices.Sort(symbols)
+	return symbols
+}
+
+// complete marks a rolled up candle as closed and keeps it while its sources may be revised
+func (r *Rollup) complete(key candleKey, rolled *rolledCandle) *OHLC {
+	delete(r.current, key)
+	r.completedUntil[key] = rolled.ohlc.CloseTime
+	if r.allowedLateness > 0 {
+		r.closed[key] = append(r.closed[key], rolled)
+	}
+
+	// Later revisions rebuild the kept candle rather than changing the emitted one
+	ohlc := *rolled.ohlc
+	ohlc.IsClosed = true
+	return &ohlc
+}
+
+// closedAt returns the completed candle of key opening at startTime if it is still kept
+func (r *Rollup) closedAt(key candleKey, startTime time.Time) *rolledCandle {
+	for _, rolled := range r.closed[key] {
+		if rolled.ohlc.OpenTime.Equal(startTime) {
+			return rolled
+		}
+	}
+	return nil
+}
+
+// expire folds the sources of a symbol that can no longer be revised into their candles, and
+// forgets the completed candles left without sources that can. Candles closed by the closer may
+// run a source interval ahead of the ticks, which the allowed lateness counts from
+func (r *Rollup) expire(symbol Symbol, precision Precision) {
+	cutoff := r.watermarks[symbol].Add(-r.allowedLateness - r.source.Duration())
+	for _, target := range r.targets {
+		key := candleKey{symbol: symbol, interval: target}
+		if rolled, ok := r.current[key]; ok {
+			rolled.fold(cutoff, precision)
+		}
+
+		closed := r.closed[key]
+		for len(closed) > 0 && closed[0].ohlc.CloseTime.Before(cutoff) {
+			closed = closed[1:]
+		}
+		for _, rolled := range closed {
+			rolled.fold(cutoff, precision)
+		}
+		if len(closed) == 0 {
+			delete(r.closed, key)
+		} else {
+			r.closed[key] = closed
+		}
+	}
+}
+
+// add merges a source candle into the rolled up candle, replacing the source with the same open
+// time if there is one, and reports whether it did; sources already folded into base are ignored
+func (c *rolledCandle) add(src *OHLC, precision Precision) bool {
+	if src.OpenTime.Before(c.folded) {
+		log.Printf("Skipping rollup of expired %s candle for symbol=%s at time=%s", src.Interval, src.Symbol, src.OpenTime.Format(time.RFC3339))
+		return false
+	}
+
+	// Keep a copy, the source candle belongs to the caller
+	copied := *src
+	src = &copied
+
+	i, found := slices.BinarySearchFunc(c.recent, src.OpenTime, func(ohlc *OHLC, openTime time.Time) int {
+		return ohlc.OpenTime.Compare(openTime)
+	})
+	if found {
+		c.recent[i] = src
+	} else {
+		c.recent = slices.Insert(c.recent, i, src)
+	}
+
+	// Appending the next source merges it, anything else rebuilds the candle
+	if !found && i == len(c.recent)-1 && (c.base != nil || i > 0) {
+		merge(c.ohlc, src, precision)
+		return true
+	}
+	c.rebuild(precision)
+	return true
+}
+
+// contains reports whether the source candle opening at openTime was merged into the candle
+func (c *rolledCandle) contains(openTime time.Time) bool {
+	if openTime.Before(c.folded) {
+		return true
+	}
+	return len(c.recent) > 0 && !c.recent[len(c.recent)-1].OpenTime.Before(openTime)
+}
+
+// fold merges the sources closing before cutoff into base
+func (c *rolledCandle) fold(cutoff time.Time, precision Precision) {
+	n := 0
+	for n < len(c.recent) && c.recent[n].CloseTime.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	if c.base == nil {
+		base := *c.ohlc
+		c.base = seed(&base, c.recent[0])
+		merge(c.base, c.recent[0], precision)
+		c.folded = c.recent[0].CloseTime
+		c.recent = c.recent[1:]
+		n--
+	}
+	if n == 0 {
+		c.recent = slices.Clone(c.recent)
+		return
+	}
+	for _, src := range c.recent[:n] {
+		merge(c.base, src, precision)
+	}
+	c.folded = c.recent[n-1].CloseTime
+	c.recent = slices.Clone(c.recent[n:])
+}
+
+// rebuild recomputes the candle from base and the recent sources
+func (c *rolledCandle) rebuild(precision Precision) {
+	sources := c.recent
+	var ohlc OHLC
+	if c.base != nil {
+		ohlc = *c.base
+	} else {
+		ohlc = *c.ohlc
+		seed(&ohlc, sources[0])
+		merge(&ohlc, sources[0], precision)
+		sources = sources[1:]
+	}
+	for _, src := range sources {
+		merge(&ohlc, src, precision)
+	}
+	c.ohlc = &ohlc
+}
+
+// seed resets a rolled up candle to start with its first source candle, ready for merging it
+func seed(dst, first *OHLC) *OHLC {
+	*dst = OHLC{
+		Symbol:    dst.Symbol,
+		Interval:  dst.Interval,
+		BarType:   dst.BarType,
+		Open:      first.Open,
+		High:      first.High,
+		Low:       first.Low,
+		OpenTime:  dst.OpenTime,
+		CloseTime: dst.CloseTime,
+		Synthetic: true,
+	}
+	return dst
+}
+
+// RollupCandles builds target candles from closed source candles sorted by open time, for
+// backfilling higher intervals from stored candles; only target candles closing at or before
+// end are returned
+func RollupCandles(candles []*OHLC, target Interval, end time.Time, precisions Precisions, calendar Calendar) []*OHLC {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	rollup := NewRollup(candles[0].Interval, []Interval{target}, precisions, calendar, 0)
+	var rolled []*OHLC
+	for _, ohlc := range candles {
+		rolled = append(rolled, rollup.Add(ohlc)...)
+	}
+	return append(rolled, rollup.Flush(end)...)
+}
+
+// merge folds the next source candle into a rolled up candle
+func merge(dst, src *OHLC, precision Precision) {
+	if dst.Trades == 0 {
+		dst.FirstTradeID = src.FirstTradeID
+	}
+	if src.Trades > 0 {
+		dst.LastTradeID = src.LastTradeID
+	}
+	dst.High = decimal.Max(dst.High, src.High)
+	dst.Low = decimal.Min(dst.Low, src.Low)
+	dst.Close = src.Close
+	dst.Volume = dst.Volume.Add(src.Volume)
+	dst.QuoteVolume = dst.QuoteVolume.Add(src.QuoteVolume)
+	dst.TakerBuyVolume = dst.TakerBuyVolume.Add(src.TakerBuyVolume)
+	dst.TakerBuyQuoteVolume = dst.TakerBuyQuoteVolume.Add(src.TakerBuyQuoteVolume)
+	if dst.Volume.IsPositive() {
+		dst.VWAP = dst.QuoteVolume.DivRound(dst.Volume, precision.Price)
+	} else {
+		dst.VWAP = src.VWAP
+	}
+	dst.Trades += src.Trades
+	dst.Synthetic = dst.Synthetic && src.Synthetic
+}
@@ -0,0 +1,214 @@
+package candlestick
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// minuteCandles builds consecutive closed 1m candles for BTCUSDT from their close prices
+func minuteCandles(start time.Time, closes ...string) []*OHLC {
+	candles := make([]*OHLC, 0, len(closes))
+	open := dec("100")
+	for i, close := range closes {
+		price := dec(close)
+		candles = append(candles, &OHLC{
+			Symbol:       BTCUSDT,
+			Interval:     Interval1m,
+			Open:         open,
+			High:         decimal.Max(open, price).Add(dec("1")),
+			Low:          decimal.Min(open, price).Sub(dec("1")),
+			Close:        price,
+			Volume:       dec("2"),
+			QuoteVolume:  price.Mul(dec("2")),
+			Trades:       2,
+			FirstTradeID: int64(2*i + 1),
+			LastTradeID:  int64(2*i + 2),
+			OpenTime:     start.Add(time.Duration(i) * time.Minute),
+			CloseTime:    start.Add(time.Duration(i+1) * time.Minute),
+			IsClosed:     true,
+		})
+		open = price
+	}
+	return candles
+}
+
+func TestRollupAdd(t *testing.T) {
+	rollup := NewRollup(Interval1m, []Interval{Interval5m, Interval1h}, DefaultPrecisions, DefaultCalendar, 0)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := minuteCandles(start, "101", "104", "98", "102", "103", "105")
+
+	var completed []*OHLC
+	for _, ohlc := range candles {
+		completed = append(completed, rollup.Add(ohlc)...)
+	}
+
+	// The fifth 1m candle ends the first 5m candle, the sixth starts the next one
+	if len(completed) != 1 {
+		t.Fatalf("Expected one completed candle, got %d", len(completed))
+	}
+	ohlc := completed[0]
+	if ohlc.Interval != Interval5m || !ohlc.OpenTime.Equal(start) || !ohlc.IsClosed {
+		t.Fatalf("Unexpected rolled up candle: %+v", ohlc)
+	}
+	if !ohlc.Open.Equal(dec("100")) || !ohlc.High.Equal(dec("105")) || !ohlc.Low.Equal(dec("97")) || !ohlc.Close.Equal(dec("103")) {
+		t.Errorf("Unexpected prices: open=%s high=%s low=%s close=%s", ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close)
+	}
+	if !ohlc.Volume.Equal(dec("10")) || ohlc.Trades != 10 || ohlc.FirstTradeID != 1 || ohlc.LastTradeID != 10 {
+		t.Errorf("Unexpected volume %s or trades %d from %d to %d", ohlc.Volume, ohlc.Trades, ohlc.FirstTradeID, ohlc.LastTradeID)
+	}
+	if !ohlc.VWAP.Equal(dec("101.6")) {
+		t.Errorf("Expected VWAP 101.6, got %s", ohlc.VWAP)
+	}
+
+	// Candles of other intervals are not rolled up
+	if completed := rollup.Add(ohlc); len(completed) != 0 {
+		t.Errorf("Expected a 5m candle to be ignored, got %+v", completed)
+	}
+
+	// Flushing completes the candles whose close time has passed
+	completed = rollup.Flush(start.Add(10 * time.Minute))
+	if len(completed) != 1 || completed[0].Interval != Interval5m || !completed[0].Close.Equal(dec("105")) {
+		t.Fatalf("Expected the partial 5m candle to be flushed, got %+v", completed)
+	}
+}
+
+func TestRollupCandles(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := minuteCandles(start, "101", "104", "98", "102", "103", "105", "106")
+
+	// Gaps are allowed, and only candles closing before the end of the backfill are returned
+	candles = append(candles[:2], candles[3:]...)
+	rolled := RollupCandles(candles, Interval5m, start.Add(7*time.Minute), DefaultPrecisions, DefaultCalendar)
+	if len(rolled) != 1 {
+		t.Fatalf("Expected one rolled up candle, got %d", len(rolled))
+	}
+	if !rolled[0].Volume.Equal(dec("8")) || rolled[0].Trades != 8 || !rolled[0].Close.Equal(dec("103")) {
+		t.Errorf("Unexpected rolled up candle: %+v", rolled[0])
+	}
+
+	rolled = RollupCandles(candles, Interval5m, start.Add(10*time.Minute), DefaultPrecisions, DefaultCalendar)
+	if len(rolled) != 2 || !rolled[1].OpenTime.Equal(start.Add(5*time.Minute)) || !rolled[1].Close.Equal(dec("106")) {
+		t.Fatalf("Expected two rolled up candles, got %+v", rolled)
+	}
+}
+
+func TestRollupAddRevision(t *testing.T) {
+	rollup := NewRollup(Interval1m, []Interval{Interval5m}, DefaultPrecisions, DefaultCalendar, 2*time.Minute)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := minuteCandles(start, "101", "104", "98", "102", "103", "105", "106", "107", "108", "109")
+	for _, ohlc := range candles[:6] {
+		rollup.Add(ohlc)
+	}
+
+	// A revised source candle rebuilds the completed candle containing it as its next revision
+	revised := *candles[4]
+	revised.Close = dec("99")
+	revised.Low = dec("98")
+	revised.Volume = dec("3")
+	revised.Trades = 3
+	revised.Revision = 1
+	completed := rollup.Add(&revised)
+	if len(completed) != 1 {
+		t.Fatalf("Expected one revised candle, got %d", len(completed))
+	}
+	ohlc := completed[0]
+	if !ohlc.OpenTime.Equal(start) || ohlc.Revision != 1 || !ohlc.IsClosed {
+		t.Fatalf("Unexpected revised candle: %+v", ohlc)
+	}
+	if !ohlc.Open.Equal(dec("100")) || !ohlc.Close.Equal(dec("99")) || !ohlc.Volume.Equal(dec("11")) || ohlc.Trades != 11 {
+		t.Errorf("Unexpected revised candle: open=%s close=%s volume=%s trades=%d", ohlc.Open, ohlc.Close, ohlc.Volume, ohlc.Trades)
+	}
+
+	// Revising another source within the allowed lateness counts the next revision
+	revised = *candles[2]
+	revised.High = dec("110")
+	revised.Revision = 1
+	completed = rollup.Add(&revised)
+	if len(completed) != 1 || completed[0].Revision != 2 || !completed[0].High.Equal(dec("110")) || !completed[0].Close.Equal(dec("99")) {
+		t.Fatalf("Expected the second revision, got %+v", completed)
+	}
+
+	// Revisions do not leak into the next candle
+	completed = nil
+	for _, ohlc := range candles[6:] {
+		completed = append(completed, rollup.Add(ohlc)...)
+	}
+	if len(completed) != 1 || !completed[0].OpenTime.Equal(start.Add(5*time.Minute)) || !completed[0].Volume.Equal(dec("10")) || completed[0].Revision != 0 {
+		t.Fatalf("Expected the second 5m candle, got %+v", completed)
+	}
+
+	// Sources closing beyond the allowed lateness no longer revise the completed candle
+	revised = *candles[3]
+	revised.Revision = 1
+	if completed := rollup.Add(&revised); len(completed) != 0 {
+		t.Errorf("Expected an expired revision to be ignored, got %+v", completed)
+	}
+}
+
+func TestRollupCurrent(t *testing.T) {
+	rollup := NewRollup(Interval1m, []Interval{Interval5m}, DefaultPrecisions, DefaultCalendar, 0)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := minuteCandles(start, "101", "104", "98", "102", "103", "105")
+
+	// Nothing is in progress before the first source candle
+	if ohlc := rollup.Current(BTCUSDT, Interval5m, nil); ohlc != nil {
+		t.Fatalf("Expected no candle in progress, got %+v", ohlc)
+	}
+
+	for _, ohlc := range candles[:2] {
+		rollup.Add(ohlc)
+	}
+	if symbols := rollup.Updated(); len(symbols) != 1 || symbols[0] != BTCUSDT {
+		t.Errorf("Expected BTCUSDT to be updated, got %v", symbols)
+	}
+	if symbols := rollup.Updated(); len(symbols) != 0 {
+		t.Errorf("Expected no updated symbols, got %v", symbols)
+	}
+
+	// The source candle in progress is merged into the rolled up candle, once
+	inProgress := *candles[2]
+	inProgress.IsClosed = false
+	for range 2 {
+		ohlc := rollup.Current(BTCUSDT, Interval5m, &inProgress)
+		if ohlc == nil || !ohlc.OpenTime.Equal(start) || ohlc.IsClosed {
+			t.Fatalf("Unexpected candle in progress: %+v", ohlc)
+		}
+		if !ohlc.Close.Equal(dec("98")) || !ohlc.Low.Equal(dec("97")) || !ohlc.Volume.Equal(dec("6")) || ohlc.Trades != 6 {
+			t.Errorf("Unexpected candle in progress: close=%s low=%s volume=%s trades=%d", ohlc.Close, ohlc.Low, ohlc.Volume, ohlc.Trades)
+		}
+	}
+
+	// Without it the rolled up candle has the closed sources only, and once the source closes it is
+	// not merged twice
+	if ohlc := rollup.Current(BTCUSDT, Interval5m, nil); !ohlc.Volume.Equal(dec("4")) {
+		t.Errorf("Expected volume 4 without the source in progress, got %s", ohlc.Volume)
+	}
+	rollup.Add(candles[2])
+	if ohlc := rollup.Current(BTCUSDT, Interval5m, &inProgress); !ohlc.Volume.Equal(dec("6")) {
+		t.Errorf("Expected volume 6 after the source closed, got %s", ohlc.Volume)
+	}
+
+	// A source in progress past the rolled up candle opens the next one
+	for _, ohlc := range candles[3:5] {
+		rollup.Add(ohlc)
+	}
+	inProgress = *candles[5]
+	inProgress.IsClosed = false
+	ohlc := rollup.Current(BTCUSDT, Interval5m, &inProgress)
+	if ohlc == nil || !ohlc.OpenTime.Equal(start.Add(5*time.Minute)) || !ohlc.CloseTime.Equal(start.Add(10*time.Minute)) {
+		t.Fatalf("Unexpected candle in progress: %+v", ohlc)
+	}
+	if !ohlc.Open.Equal(dec("103")) || !ohlc.Close.Equal(dec("105")) || !ohlc.Volume.Equal(dec("2")) || ohlc.FirstTradeID != 11 {
+		t.Errorf("Unexpected candle in progress: %+v", ohlc)
+	}
+
+	// Intervals the rollup does not produce have no candles in progress
+	if ohlc := rollup.Current(BTCUSDT, Interval1h, &inProgress); ohlc != nil {
+		t.Errorf("Expected no 1h candle in progress, got %+v", ohlc)
+	}
+}
@@ -0,0 +1,166 @@
+package candlestick
+
+import (
+	"hash/fnv"
+	"slices"
+	"time"
+)
+
+// shardedAggregator implements the Aggregator interface by partitioning symbols across
+// independent aggregators, each with its own state and lock, so that ticks of different shards
+// never contend. Ticks of a symbol always reach the same shard, so a single goroutine feeding
+// each shard keeps them in order
+type shardedAggregator struct {
+	shards []Aggregator
+}
+
+// NewShardedAggregator creates an aggregator spreading symbols over the given number of shards,
+// each maintaining candles as configured
+func NewShardedAggregator(config AggregatorConfig, shards int) Aggregator {
+	if shards < 1 {
+		shards = 1
+	}
+
+	s := &shardedAggregator{shards: make([]Aggregator, shards)}
+	for i := range s.shards {
+		s.shards[i] = NewAggregator(config)
+	}
+	return s
+}
+
+// ShardOf returns the shard of a symbol among shards, which is stable across restarts
+func ShardOf(symbol Symbol, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// shard returns the aggregator holding the candles of a symbol
+func (s *shardedAggregator) shard(symbol Symbol) Aggregator {
+	return s.shards[ShardOf(symbol, len(s.shards))]
+}
+
+// Process handles a new tick in the shard of its symbol
+func (s *shardedAggregator) Process(tick Tick) ([]*OHLC, error) {
+	return s.shard(tick.Symbol).Process(tick)
+}
+
+// Replay applies a tick loaded from storage in the shard of its symbol
+func (s *shardedAggregator) Replay(tick Tick) []*OHLC {
+	return s.shard(tick.Symbol).Replay(tick)
+}
+
+// Restore replaces the in-progress candles of every shard with those of its symbols
+func (s *shardedAggregator) Restore(candles []*OHLC) {
+	partitions := make([][]*OHLC, len(s.shards))
+	for _, ohlc := range candles {
+		i := ShardOf(ohlc.Symbol, len(s.shards))
+		partitions[i] = append(partitions[i], ohlc)
+	}
+	for i, shard := range s.shards {
+		shard.Restore(partitions[i])
+	}
+}
+
+// Flush completes and returns the in-progress OHLCs of every shard whose close time is at or
+// before now
+func (s *shardedAggregator) Flush(now time.Time) []*OHLC {
+	return s.collect(func(shard Aggregator) []*OHLC { return shard.Flush(now) })
+}
+
+// Current returns a copy of the in-progress OHLC for a symbol and interval
+func (s *shardedAggregator) Current(symbol Symbol, interval Interval) *OHLC {
+	return s.shard(symbol).Current(symbol, interval)
+}
+
+// Snapshot returns copies of the in-progress OHLCs of every shard
+func (s *shardedAggregator) Snapshot() []*OHLC {
+	return s.collect(Aggregator.Snapshot)
+}
+
+// Updates returns copies of the in-progress OHLCs of every shard that changed since the
+// previous call
+func (s *shardedAggregator) Updates() []*OHLC {
+	return s.collect(Aggregator.Updates)
+}
+
+// Stats returns the late tick counters summed over every shard
+func (s *shardedAggregator) Stats() AggregatorStats {
+	var stats AggregatorStats
+	for _, shard := range s.shards {
+		shardStats := shard.Stats()
+		stats.Revisions += shardStats.Revisions
+		stats.DroppedTicks += shardStats.DroppedTicks
+	}
+	return stats
+}
+
+// collect concatenates the candles returned by each shard, in the order of a single aggregator
+func (s *shardedAggregator) collect(get func(Aggregator) []*OHLC) []*OHLC {
+	var ohlcs []*OHLC
+	for _, shard := range s.shards {
+		ohlcs = append(ohlcs, get(shard)...)
+	}
+	sortOHLCs(ohlcs)
+	return ohlcs
+}
+
+// ShardedFootprintAggregator partitions symbols across independent footprint aggregators the same
+// way as the sharded candle aggregator, so that the goroutine feeding a shard of candles never
+// contends with the others for the footprints of its ticks
+type ShardedFootprintAggregator struct {
+	shards []*FootprintAggregator
+}
+
+// NewShardedFootprintAggregator creates a footprint aggregator spreading symbols over the given
+// number of shards, each building footprints as configured
+func NewShardedFootprintAggregator(config FootprintConfig, shards int) *ShardedFootprintAggregator {
+	if shards < 1 {
+		shards = 1
+	}
+
+	s := &ShardedFootprintAggregator{shards: make([]*FootprintAggregator, shards)}
+	for i := range s.shards {
+		s.shards[i] = NewFootprintAggregator(config)
+	}
+	return s
+}
+
+// shard returns the footprint aggregator holding the footprints of a symbol
+func (s *ShardedFootprintAggregator) shard(symbol Symbol) *FootprintAggregator {
+	return s.shards[ShardOf(symbol, len(s.shards))]
+}
+
+// Process adds a tick to the footprints of its candles in the shard of its symbol
+func (s *ShardedFootprintAggregator) Process(tick Tick) {
+	s.shard(tick.Symbol).Process(tick)
+}
+
+// Close completes the footprint of a closed candle in the shard of its symbol
+func (s *ShardedFootprintAggregator) Close(ohlc *OHLC) *Footprint {
+	return s.shard(ohlc.Symbol).Close(ohlc)
+}
+
+// Prune discards the footprints of every shard of candles that closed before now
+func (s *ShardedFootprintAggregator) Prune(now time.Time) {
+	for _, shard := range s.shards {
+		shard.Prune(now)
+	}
+}
+
+// Updates returns copies of the in-progress footprints of every shard that changed since the
+// last call
+func (s *ShardedFootprintAggregator) Updates() []*Footprint {
+	var updates []*Footprint
+	for _, shard := range s.shards {
+		updates = append(updates, shard.Updates()...)
+	}
+	slices.SortStableFunc(updates, func(a, b *Footprint) int {
+		return a.OpenTime.Compare(b.OpenTime)
+	})
+	return updates
+}
@@ -0,0 +1,113 @@
+package candlestick
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedAggregatorMatchesAggregator(t *testing.T) {
+	config := AggregatorConfig{Intervals: []Interval{Interval1m, Interval5m}, Calendar: DefaultCalendar}
+	single := NewAggregator(config)
+	sharded := NewShardedAggregator(config, 4)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	symbols := []Symbol{BTCUSDT, ETHUSDT, PEPEUSDT, "SOLUSDT", "BNBUSDT"}
+	var expected, got []*OHLC
+	for i := range 200 {
+		tick := Tick{Symbol: symbols[i%len(symbols)], Price: dec(fmt.Sprint(100 + i%7)), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * 10 * time.Second)}
+		completed, err := single.Process(tick)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected = append(expected, completed...)
+		if completed, err = sharded.Process(tick); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, completed...)
+	}
+
+	now := start.Add(time.Hour)
+	expected = append(expected, single.Flush(now)...)
+	got = append(got, sharded.Flush(now)...)
+	sortOHLCs(expected)
+	sortOHLCs(got)
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d candles, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if got[i].Symbol != expected[i].Symbol || got[i].Interval != expected[i].Interval || !got[i].OpenTime.Equal(expected[i].OpenTime) || !got[i].Close.Equal(expected[i].Close) || !got[i].Volume.Equal(expected[i].Volume) {
+			t.Errorf("Candle %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestShardedFootprintAggregator(t *testing.T) {
+	footprints := NewShardedFootprintAggregator(FootprintConfig{Intervals: []Interval{Interval1m}, Precisions: DefaultPrecisions, Calendar: DefaultCalendar}, 4)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	symbols := []Symbol{BTCUSDT, ETHUSDT, PEPEUSDT, "SOLUSDT", "BNBUSDT"}
+	for i, symbol := range symbols {
+		footprints.Process(Tick{Symbol: symbol, Price: dec("100"), Quantity: dec("1"), Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	if updates := footprints.Updates(); len(updates) != len(symbols) {
+		t.Fatalf("Expected an update per symbol, got %+v", updates)
+	}
+	for _, symbol := range symbols {
+		footprint := footprints.Close(&OHLC{Symbol: symbol, Interval: Interval1m, OpenTime: start, IsClosed: true})
+		if footprint == nil || footprint.Symbol != symbol || len(footprint.Levels) != 1 {
+			t.Errorf("Unexpected footprint of %s: %+v", symbol, footprint)
+		}
+	}
+}
+
+// BenchmarkShardedAggregator feeds ticks of 256 symbols through one goroutine per shard into the
+// candles and footprints, logging as usual, the way the service does, reporting the throughput in
+// ticks per second
+func BenchmarkShardedAggregator(b *testing.B) {
+	symbols := make([]Symbol, 256)
+	for i := range symbols {
+		symbols[i] = Symbol(fmt.Sprintf("SYM%03dUSDT", i))
+	}
+	config := AggregatorConfig{Intervals: []Interval{Interval1m, Interval5m, Interval1h}, Calendar: DefaultCalendar}
+	footprintConfig := FootprintConfig{Intervals: []Interval{Interval1m, Interval1h}, Precisions: DefaultPrecisions, Calendar: DefaultCalendar}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	price, quantity := dec("100"), dec("1")
+
+	for _, shards := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			agg := NewShardedAggregator(config, shards)
+			footprints := NewShardedFootprintAggregator(footprintConfig, shards)
+			partitions := make([][]Symbol, shards)
+			for _, symbol := range symbols {
+				i := ShardOf(symbol, shards)
+				partitions[i] = append(partitions[i], symbol)
+			}
+
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			for _, partition := range partitions {
+				if len(partition) == 0 {
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range b.N / shards {
+						tick := Tick{Symbol: partition[i%len(partition)], Price: price, Quantity: quantity, Timestamp: start.Add(time.Duration(i) * time.Millisecond)}
+						footprints.Process(tick)
+						if _, err := agg.Process(tick); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ticks/s")
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package candlestick
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// TickWriterConfig configures the buffering and batching of tick writes
+type TickWriterConfig struct {
+	BufferSize    int           // ticks waiting to be written, beyond which new ticks are dropped
+	BatchSize     int           // most ticks stored at once
+	FlushInterval time.Duration // longest a tick waits for its batch to fill up
+	MaxRetries    int           // retries of a failed batch, waiting FlushInterval in between, before it is dropped
+}
+
+// DefaultTickWriterConfig buffers a few seconds of busy trading and writes up to 500 ticks at once
+var DefaultTickWriterConfig = TickWriterConfig{
+	BufferSize:    100000,
+	BatchSize:     500,
+	FlushInterval: 200 * time.Millisecond,
+	MaxRetries:    3,
+}
+
+// TickWriterStats holds counters about tick persistence
+type TickWriterStats struct {
+	Written int64 `json:"written"` // ticks stored
+	Dropped int64 `json:"dropped"` // ticks discarded because the buffer was full
+	Failed  int64 `json:"failed"`  // ticks discarded after their batch failed every retry
+	Backlog int64 `json:"backlog"` // ticks buffered or in a batch not stored yet
+}
+
+// TickWriter persists ticks asynchronously in batches, so that aggregation never waits for or
+// fails on storage. Ticks are dropped rather than blocking the caller when the buffer is full
+type TickWriter struct {
+	storage Storage
+	clock   Clock
+	config  TickWriterConfig
+	ticks   chan *Tick
+	done    chan struct{}
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+	pending atomic.Int64 // ticks taken from the buffer but not stored yet
+}
+
+// NewTickWriter creates a tick writer storing ticks in storage once Run is called, with a zero
+// buffer size, batch size or flush interval taking its default
+func NewTickWriter(storage Storage, clock Clock, config TickWriterConfig) *TickWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultTickWriterConfig.BufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultTickWriterConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultTickWriterConfig.FlushInterval
+	}
+
+	return &TickWriter{
+		storage: storage,
+		clock:   clock,
+		config:  config,
+		ticks:   make(chan *Tick, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// Write queues a tick for storage without blocking, returning false if the buffer is full and
+// the tick was dropped
+func (w *TickWriter) Write(tick Tick) bool {
+	select {
+	case w.ticks <- &tick:
+		return true
+	default:
+		if dropped := w.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("Tick buffer full, dropped %d ticks so far", dropped)
+		}
+		return false
+	}
+}
+
+// Run stores queued ticks until ctx is cancelled, then stores the ticks still buffered and
+// closes Done
+func (w *TickWriter) Run(ctx context.Context) {
+	defer close(w.done)
+
+	batch := make([]*Tick, 0, w.config.BatchSize)
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			w.drain(ctx, batch)
+			return
+		case tick := <-w.ticks:
+			batch = append(batch, tick)
+			w.pending.Add(1)
+			if len(batch) < w.config.BatchSize {
+				if flush == nil {
+					flush = w.clock.After(w.config.FlushInterval)
+				}
+				continue
+			}
+		case <-flush:
+		}
+
+		w.store(ctx, batch)
+		batch = make([]*Tick, 0, w.config.BatchSize)
+		flush = nil
+	}
+}
+
+// drain stores the pending batch and every buffered tick on shutdown, retrying failed batches
+// without waiting
+func (w *TickWriter) drain(ctx context.Context, batch []*Tick) {
+	for {
+		select {
+		case tick := <-w.ticks:
+			batch = append(batch, tick)
+			w.pending.Add(1)
+			if len(batch) < w.config.BatchSize {
+				continue
+			}
+			w.store(ctx, batch)
+			batch = make([]*Tick, 0, w.config.BatchSize)
+		default:
+			if len(batch) > 0 {
+				log.Printf("Storing %d buffered ticks on shutdown", len(batch))
+				w.store(ctx, batch)
+			}
+			return
+		}
+	}
+}
+
+// store writes a batch, retrying failures up to MaxRetries times and waiting in between until
+// ctx is cancelled
+func (w *TickWriter) store(ctx context.Context, batch []*Tick) {
+	defer w.pending.Add(-int64(len(batch)))
+
+	for attempt := 0; ; attempt++ {
+		err := w.storage.StoreTicks(batch)
+		if err == nil {
+			w.written.Add(int64(len(batch)))
+			return
+		}
+		if attempt >= w.config.MaxRetries {
+			w.failed.Add(int64(len(batch)))
+			log.Printf("Dropping %d ticks after %d failed attempts: %v", len(batch), attempt+1, err)
+			return
+		}
+
+		log.Printf("Error storing %d ticks, retrying: %v", len(batch), err)
+		select {
+		case <-ctx.Done():
+			// Keep retrying without waiting while the buffered ticks are drained
+		case <-w.clock.After(w.config.FlushInterval):
+		}
+	}
+}
+
+// Done is closed once Run returns after storing the buffered ticks
+func (w *TickWriter) Done() <-chan struct{} {
+	return w.done
+}
+
+// Stats returns counters about tick persistence
+func (w *TickWriter) Stats() TickWriterStats {
+	return TickWriterStats{
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Failed:  w.failed.Load(),
+		Backlog: int64(len(w.ticks)) + w.pending.Load(),
+	}
+}
@@ -0,0 +1,120 @@
+package candlestick
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyStorage fails StoreTicks while failing is set
+type flakyStorage struct {
+	Storage
+	mu      sync.Mutex
+	failing bool
+	batches [][]*Tick
+}
+
+func (f *flakyStorage) StoreTicks(ticks []*Tick) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		return errors.New("connection refused")
+	}
+	f.batches = append(f.batches, ticks)
+	return nil
+}
+
+func (f *flakyStorage) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+func (f *flakyStorage) stored() [][]*Tick {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTickWriterBatches(t *testing.T) {
+	storage := &flakyStorage{Storage: NewMockStorage()}
+	clock := NewMockClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	writer := NewTickWriter(storage, clock, TickWriterConfig{BufferSize: 10, BatchSize: 3, FlushInterval: time.Second, MaxRetries: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go writer.Run(ctx)
+
+	// A full batch is written right away
+	for i := range 4 {
+		writer.Write(Tick{Symbol: BTCUSDT, TradeID: int64(i)})
+	}
+	waitFor(t, func() bool { return len(storage.stored()) == 1 })
+	if len(storage.stored()[0]) != 3 {
+		t.Fatalf("Expected a batch of 3 ticks, got %d", len(storage.stored()[0]))
+	}
+
+	// The rest is written once the flush interval passes
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return len(storage.stored()) == 2 })
+
+	// Failed batches are retried
+	storage.setFailing(true)
+	writer.Write(Tick{Symbol: BTCUSDT, TradeID: 4})
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	storage.setFailing(false)
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return len(storage.stored()) == 3 })
+
+	// Buffered ticks are written on shutdown
+	writer.Write(Tick{Symbol: BTCUSDT, TradeID: 5})
+	cancel()
+	<-writer.Done()
+
+	stats := writer.Stats()
+	if stats.Written != 6 || stats.Dropped != 0 || stats.Failed != 0 || stats.Backlog != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestTickWriterDropsWhenFull(t *testing.T) {
+	storage := &flakyStorage{Storage: NewMockStorage(), failing: true}
+	clock := NewMockClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	writer := NewTickWriter(storage, clock, TickWriterConfig{BufferSize: 2, BatchSize: 1, FlushInterval: time.Second, MaxRetries: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go writer.Run(ctx)
+
+	// The first tick is taken by the writer and retried, two more fill the buffer
+	writer.Write(Tick{Symbol: BTCUSDT, TradeID: 1})
+	clock.BlockUntil(1)
+	for i := range 3 {
+		writer.Write(Tick{Symbol: BTCUSDT, TradeID: int64(i + 2)})
+	}
+	if stats := writer.Stats(); stats.Dropped != 1 || stats.Backlog != 3 {
+		t.Errorf("Expected 1 dropped tick and a backlog of 3, got %+v", stats)
+	}
+
+	// The batch is given up after its retry
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return writer.Stats().Failed == 1 })
+
+	cancel()
+	<-writer.Done()
+}
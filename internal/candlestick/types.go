@@ -2,6 +2,8 @@ package candlestick
 
 import (
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Symbol represents a trading pair
@@ -15,40 +17,115 @@ const (
 
 // Tick represents a single price update from the exchange
 type Tick struct {
-	Symbol    Symbol    `json:"symbol" gorm:"column:symbol"`
-	Price     float64   `json:"price" gorm:"column:price"`
-	Quantity  float64   `json:"quantity" gorm:"column:quantity"`
-	Timestamp time.Time `json:"timestamp" gorm:"column:timestamp"`
+	Symbol       Symbol          `json:"symbol" gorm:"column:symbol"`
+	Price        decimal.Decimal `json:"price" gorm:"column:price;type:numeric"`
+	Quantity     decimal.Decimal `json:"quantity" gorm:"column:quantity;type:numeric"`
+	Timestamp    time.Time       `json:"timestamp" gorm:"column:timestamp"`
+	TradeID      int64           `json:"trade_id" gorm:"column:trade_id"`             // aggregate trade ID
+	FirstTradeID int64           `json:"first_trade_id" gorm:"column:first_trade_id"` // first trade ID in the aggregate trade
+	LastTradeID  int64           `json:"last_trade_id" gorm:"column:last_trade_id"`   // last trade ID in the aggregate trade
+	IsBuyerMaker bool            `json:"is_buyer_maker" gorm:"column:is_buyer_maker"` // true when the seller was the aggressor
+}
+
+// TradeCount returns the number of exchange trades the tick aggregates
+func (t Tick) TradeCount() int64 {
+	if t.LastTradeID < t.FirstTradeID {
+		return 1
+	}
+	return t.LastTradeID - t.FirstTradeID + 1
 }
 
 // OHLC represents a candlestick with open, high, low, and close prices
 type OHLC struct {
-	Symbol    Symbol    `json:"symbol" gorm:"column:symbol"`
-	Open      float64   `json:"open" gorm:"column:open"`
-	High      float64   `json:"high" gorm:"column:high"`
-	Low       float64   `json:"low" gorm:"column:low"`
-	Close     float64   `json:"close" gorm:"column:close"`
-	Volume    float64   `json:"volume" gorm:"column:volume"`
-	OpenTime  time.Time `json:"open_time" gorm:"column:open_time"`
-	CloseTime time.Time `json:"close_time" gorm:"column:close_time"`
+	Symbol              Symbol             `json:"symbol" gorm:"column:symbol"`
+	Interval            Interval           `json:"interval" gorm:"column:interval"` // time interval or bar identifier, e.g. "volume:10"
+	BarType             BarType            `json:"bar_type" gorm:"column:bar_type"`
+	Open                decimal.Decimal    `json:"open" gorm:"column:open;type:numeric"`
+	High                decimal.Decimal    `json:"high" gorm:"column:high;type:numeric"`
+	Low                 decimal.Decimal    `json:"low" gorm:"column:low;type:numeric"`
+	Close               decimal.Decimal    `json:"close" gorm:"column:close;type:numeric"`
+	Volume              decimal.Decimal    `json:"volume" gorm:"column:volume;type:numeric"`                                 // base asset volume
+	QuoteVolume         decimal.Decimal    `json:"quote_volume" gorm:"column:quote_volume;type:numeric"`                     // quote asset volume, sum of price × quantity
+	TakerBuyVolume      decimal.Decimal    `json:"taker_buy_volume" gorm:"column:taker_buy_volume;type:numeric"`             // base volume bought by aggressive buyers
+	TakerBuyQuoteVolume decimal.Decimal    `json:"taker_buy_quote_volume" gorm:"column:taker_buy_quote_volume;type:numeric"` // quote volume bought by aggressive buyers
+	VWAP                decimal.Decimal    `json:"vwap" gorm:"column:vwap;type:numeric"`                                     // volume weighted average price
+	Trades              int64              `json:"trades" gorm:"column:trades"`                                              // number of exchange trades
+	FirstTradeID        int64              `json:"first_trade_id" gorm:"column:first_trade_id"`
+	LastTradeID         int64              `json:"last_trade_id" gorm:"column:last_trade_id"`
+	OpenTime            time.Time          `json:"open_time" gorm:"column:open_time"`
+	CloseTime           time.Time          `json:"close_time" gorm:"column:close_time"`
+	Synthetic           bool               `json:"synthetic" gorm:"column:synthetic"` // true for gap-filled candles without trades
+	Revision            int                `json:"revision" gorm:"column:revision"`   // incremented each time late ticks correct the candle
+	IsClosed            bool               `json:"is_closed" gorm:"-"`                // false while the candle is still in progress
+	Indicators          map[string]float64 `json:"indicators,omitempty" gorm:"-"`     // technical indicator values, keyed by indicator identifier
 }
 
 // Aggregator defines the interface for OHLC data aggregation
 type Aggregator interface {
-	// Process handles a new tick and returns a completed OHLC if available
-	Process(tick Tick) (*OHLC, error)
-	// Current returns the current in-progress OHLC
-	Current() *OHLC
+	// Process handles a new tick and returns the OHLCs it completed or, for late ticks, revised
+	Process(tick Tick) ([]*OHLC, error)
+	// Replay applies a tick loaded from storage, skipping ticks the in-progress candles already
+	// contain, and returns the OHLCs it completed
+	Replay(tick Tick) []*OHLC
+	// Restore replaces the in-progress candles, typically with a previously saved snapshot
+	Restore(candles []*OHLC)
+	// Flush completes and returns every in-progress OHLC whose close time is at or before now
+	Flush(now time.Time) []*OHLC
+	// Current returns a copy of the in-progress OHLC for a symbol and interval, or nil if there is none
+	Current(symbol Symbol, interval Interval) *OHLC
+	// Snapshot returns copies of all in-progress OHLCs
+	Snapshot() []*OHLC
+	// Updates returns copies of the in-progress OHLCs that changed since the previous call
+	Updates() []*OHLC
+	// Stats returns counters about late tick handling
+	Stats() AggregatorStats
 }
 
 // Storage defines the interface for OHLC data persistence
 type Storage interface {
-	// Store persists an OHLC candlestick
+	// Store persists an OHLC candlestick, replacing any candle stored for the same symbol, interval
+	// and open time, and for bars other than Heikin-Ashi candles first trade
 	Store(ohlc *OHLC) error
 	// StoreTick persists a tick to the database
 	StoreTick(tick *Tick) error
-	// GetRange retrieves OHLC candlesticks for a symbol within a time range
-	GetRange(symbol Symbol, start, end time.Time) ([]*OHLC, error)
+	// StoreTicks persists a batch of ticks to the database
+	StoreTicks(ticks []*Tick) error
+	// GetRange retrieves the OHLC candlesticks of a symbol and interval opening in [start, end),
+	// by ascending open time and first trade
+	GetRange(symbol Symbol, interval Interval, start, end time.Time) ([]*OHLC, error)
+	// Query retrieves a page of OHLC candlesticks
+	Query(query RangeQuery) (*RangePage, error)
+	// GetTicks retrieves the ticks of a symbol with timestamps in [start, end), in trade order
+	GetTicks(symbol Symbol, start, end time.Time) ([]*Tick, error)
+	// SaveSnapshot replaces the saved in-progress candles with candles
+	SaveSnapshot(candles []*OHLC) error
+	// LoadSnapshot returns the in-progress candles saved by the last SaveSnapshot call
+	LoadSnapshot() ([]*OHLC, error)
+}
+
+// FootprintStorage defines the interface for persisting footprints
+type FootprintStorage interface {
+	// StoreFootprint persists a closed footprint
+	StoreFootprint(footprint *Footprint) error
+	// GetFootprints retrieves the footprints of a symbol and interval opening in [start, end)
+	GetFootprints(symbol Symbol, interval Interval, start, end time.Time) ([]*Footprint, error)
+}
+
+// RetentionStorage defines the interface for removing old ticks and candles
+type RetentionStorage interface {
+	// OldestTick returns the timestamp of the oldest stored tick, or the zero time without ticks
+	OldestTick() (time.Time, error)
+	// GetAllTicks retrieves the ticks of every symbol with timestamps in [start, end), in trade order
+	GetAllTicks(start, end time.Time) ([]*Tick, error)
+	// CountTicks counts the ticks with timestamps before before
+	CountTicks(before time.Time) (int64, error)
+	// DeleteTicks removes the ticks with timestamps before before, returning how many were removed
+	DeleteTicks(before time.Time) (int64, error)
+	// CountCandles counts the candles of an interval opening before before
+	CountCandles(interval Interval, before time.Time) (int64, error)
+	// DeleteCandles removes the candles of an interval opening before before, returning how many
+	// were removed
+	DeleteCandles(interval Interval, before time.Time) (int64, error)
 }
 
 // Streamer defines the interface for real-time OHLC data streaming
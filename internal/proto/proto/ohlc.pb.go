@@ -20,13 +20,14 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// SubscribeRequest specifies which symbols to subscribe to
+// SubscribeRequest specifies which symbols and intervals to subscribe to
 type SubscribeRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Symbols []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Symbols   []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Intervals []string `protobuf:"bytes,2,rep,name=intervals,proto3" json:"intervals,omitempty"` // e.g. "1m", "1h", "1w", "1M", configured bars such as "volume:10" or "renko:25", or "heikin_ashi:1h"; empty subscribes to every interval
 }
 
 func (x *SubscribeRequest) Reset() {
@@ -66,25 +67,100 @@ func (x *SubscribeRequest) GetSymbols() []string {
 	return nil
 }
 
+func (x *SubscribeRequest) GetIntervals() []string {
+	if x != nil {
+		return x.Intervals
+	}
+	return nil
+}
+
+// CurrentRequest specifies which in-progress candle to return
+type CurrentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol   string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval string `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"` // e.g. "1m", "tick:1000" or "heikin_ashi:1h"
+}
+
+func (x *CurrentRequest) Reset() {
+	*x = CurrentRequest{}
+	mi := &file_proto_ohlc_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CurrentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CurrentRequest) ProtoMessage() {}
+
+func (x *CurrentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ohlc_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CurrentRequest.ProtoReflect.Descriptor instead.
+func (*CurrentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ohlc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CurrentRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *CurrentRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
 // OHLCData represents a single OHLC candlestick
+// Prices and volumes are exact decimal strings, formatted with the symbol's precision
 type OHLCData struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Symbol    string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
-	Open      float64 `protobuf:"fixed64,2,opt,name=open,proto3" json:"open,omitempty"`
-	High      float64 `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`
-	Low       float64 `protobuf:"fixed64,4,opt,name=low,proto3" json:"low,omitempty"`
-	Close     float64 `protobuf:"fixed64,5,opt,name=close,proto3" json:"close,omitempty"`
-	Volume    float64 `protobuf:"fixed64,6,opt,name=volume,proto3" json:"volume,omitempty"`
-	OpenTime  int64   `protobuf:"varint,7,opt,name=open_time,json=openTime,proto3" json:"open_time,omitempty"`    // Unix timestamp in milliseconds
-	CloseTime int64   `protobuf:"varint,8,opt,name=close_time,json=closeTime,proto3" json:"close_time,omitempty"` // Unix timestamp in milliseconds
+	Symbol              string             `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	OpenTime            int64              `protobuf:"varint,7,opt,name=open_time,json=openTime,proto3" json:"open_time,omitempty"`    // Unix timestamp in milliseconds, aligned to the service calendar
+	CloseTime           int64              `protobuf:"varint,8,opt,name=close_time,json=closeTime,proto3" json:"close_time,omitempty"` // Unix timestamp in milliseconds
+	Interval            string             `protobuf:"bytes,9,opt,name=interval,proto3" json:"interval,omitempty"`                     // Candle interval, e.g. "1m", or bar identifier, e.g. "dollar:1000000"
+	Synthetic           bool               `protobuf:"varint,10,opt,name=synthetic,proto3" json:"synthetic,omitempty"`                 // Gap-filled candle without any trade, O=H=L=C=previous close
+	IsClosed            bool               `protobuf:"varint,11,opt,name=is_closed,json=isClosed,proto3" json:"is_closed,omitempty"`   // False for in-progress updates, true for the final message of a candle
+	Trades              int64              `protobuf:"varint,16,opt,name=trades,proto3" json:"trades,omitempty"`                       // Number of exchange trades
+	FirstTradeId        int64              `protobuf:"varint,17,opt,name=first_trade_id,json=firstTradeId,proto3" json:"first_trade_id,omitempty"`
+	LastTradeId         int64              `protobuf:"varint,18,opt,name=last_trade_id,json=lastTradeId,proto3" json:"last_trade_id,omitempty"`
+	Revision            int32              `protobuf:"varint,19,opt,name=revision,proto3" json:"revision,omitempty"` // 0 for the original closed candle, incremented each time late ticks correct it
+	Open                string             `protobuf:"bytes,20,opt,name=open,proto3" json:"open,omitempty"`
+	High                string             `protobuf:"bytes,21,opt,name=high,proto3" json:"high,omitempty"`
+	Low                 string             `protobuf:"bytes,22,opt,name=low,proto3" json:"low,omitempty"`
+	Close               string             `protobuf:"bytes,23,opt,name=close,proto3" json:"close,omitempty"`
+	Volume              string             `protobuf:"bytes,24,opt,name=volume,proto3" json:"volume,omitempty"`
+	QuoteVolume         string             `protobuf:"bytes,25,opt,name=quote_volume,json=quoteVolume,proto3" json:"quote_volume,omitempty"`                                                                      // Sum of price × quantity
+	TakerBuyVolume      string             `protobuf:"bytes,26,opt,name=taker_buy_volume,json=takerBuyVolume,proto3" json:"taker_buy_volume,omitempty"`                                                           // Base volume bought by aggressive buyers
+	TakerBuyQuoteVolume string             `protobuf:"bytes,27,opt,name=taker_buy_quote_volume,json=takerBuyQuoteVolume,proto3" json:"taker_buy_quote_volume,omitempty"`                                          // Quote volume bought by aggressive buyers
+	Vwap                string             `protobuf:"bytes,28,opt,name=vwap,proto3" json:"vwap,omitempty"`                                                                                                       // Volume weighted average price, rounded to the price precision
+	BarType             string             `protobuf:"bytes,29,opt,name=bar_type,json=barType,proto3" json:"bar_type,omitempty"`                                                                                  // "time", "tick", "volume", "dollar", "range", "renko" or "heikin_ashi"
+	Indicators          map[string]float64 `protobuf:"bytes,30,rep,name=indicators,proto3" json:"indicators,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"` // Configured indicator values keyed by name, e.g. "rsi:14" or "bb:20,2:upper"
 }
 
 func (x *OHLCData) Reset() {
 	*x = OHLCData{}
-	mi := &file_proto_ohlc_proto_msgTypes[1]
+	mi := &file_proto_ohlc_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -96,7 +172,7 @@ func (x *OHLCData) String() string {
 func (*OHLCData) ProtoMessage() {}
 
 func (x *OHLCData) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_ohlc_proto_msgTypes[1]
+	mi := &file_proto_ohlc_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -109,7 +185,7 @@ func (x *OHLCData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OHLCData.ProtoReflect.Descriptor instead.
 func (*OHLCData) Descriptor() ([]byte, []int) {
-	return file_proto_ohlc_proto_rawDescGZIP(), []int{1}
+	return file_proto_ohlc_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *OHLCData) GetSymbol() string {
@@ -119,83 +195,428 @@ func (x *OHLCData) GetSymbol() string {
 	return ""
 }
 
-func (x *OHLCData) GetOpen() float64 {
+func (x *OHLCData) GetOpenTime() int64 {
 	if x != nil {
-		return x.Open
+		return x.OpenTime
 	}
 	return 0
 }
 
-func (x *OHLCData) GetHigh() float64 {
+func (x *OHLCData) GetCloseTime() int64 {
 	if x != nil {
-		return x.High
+		return x.CloseTime
 	}
 	return 0
 }
 
-func (x *OHLCData) GetLow() float64 {
+func (x *OHLCData) GetInterval() string {
 	if x != nil {
-		return x.Low
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *OHLCData) GetSynthetic() bool {
+	if x != nil {
+		return x.Synthetic
+	}
+	return false
+}
+
+func (x *OHLCData) GetIsClosed() bool {
+	if x != nil {
+		return x.IsClosed
+	}
+	return false
+}
+
+func (x *OHLCData) GetTrades() int64 {
+	if x != nil {
+		return x.Trades
 	}
 	return 0
 }
 
-func (x *OHLCData) GetClose() float64 {
+func (x *OHLCData) GetFirstTradeId() int64 {
 	if x != nil {
-		return x.Close
+		return x.FirstTradeId
 	}
 	return 0
 }
 
-func (x *OHLCData) GetVolume() float64 {
+func (x *OHLCData) GetLastTradeId() int64 {
 	if x != nil {
-		return x.Volume
+		return x.LastTradeId
 	}
 	return 0
 }
 
-func (x *OHLCData) GetOpenTime() int64 {
+func (x *OHLCData) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *OHLCData) GetOpen() string {
+	if x != nil {
+		return x.Open
+	}
+	return ""
+}
+
+func (x *OHLCData) GetHigh() string {
+	if x != nil {
+		return x.High
+	}
+	return ""
+}
+
+func (x *OHLCData) GetLow() string {
+	if x != nil {
+		return x.Low
+	}
+	return ""
+}
+
+func (x *OHLCData) GetClose() string {
+	if x != nil {
+		return x.Close
+	}
+	return ""
+}
+
+func (x *OHLCData) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+func (x *OHLCData) GetQuoteVolume() string {
+	if x != nil {
+		return x.QuoteVolume
+	}
+	return ""
+}
+
+func (x *OHLCData) GetTakerBuyVolume() string {
+	if x != nil {
+		return x.TakerBuyVolume
+	}
+	return ""
+}
+
+func (x *OHLCData) GetTakerBuyQuoteVolume() string {
+	if x != nil {
+		return x.TakerBuyQuoteVolume
+	}
+	return ""
+}
+
+func (x *OHLCData) GetVwap() string {
+	if x != nil {
+		return x.Vwap
+	}
+	return ""
+}
+
+func (x *OHLCData) GetBarType() string {
+	if x != nil {
+		return x.BarType
+	}
+	return ""
+}
+
+func (x *OHLCData) GetIndicators() map[string]float64 {
+	if x != nil {
+		return x.Indicators
+	}
+	return nil
+}
+
+// FootprintLevel holds the volume traded at one price level, split by aggressor side
+type FootprintLevel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Price      string `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`                             // Lower bound of the level
+	BuyVolume  string `protobuf:"bytes,2,opt,name=buy_volume,json=buyVolume,proto3" json:"buy_volume,omitempty"`    // Base volume bought by aggressive buyers
+	SellVolume string `protobuf:"bytes,3,opt,name=sell_volume,json=sellVolume,proto3" json:"sell_volume,omitempty"` // Base volume sold by aggressive sellers
+}
+
+func (x *FootprintLevel) Reset() {
+	*x = FootprintLevel{}
+	mi := &file_proto_ohlc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FootprintLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FootprintLevel) ProtoMessage() {}
+
+func (x *FootprintLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ohlc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FootprintLevel.ProtoReflect.Descriptor instead.
+func (*FootprintLevel) Descriptor() ([]byte, []int) {
+	return file_proto_ohlc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FootprintLevel) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *FootprintLevel) GetBuyVolume() string {
+	if x != nil {
+		return x.BuyVolume
+	}
+	return ""
+}
+
+func (x *FootprintLevel) GetSellVolume() string {
+	if x != nil {
+		return x.SellVolume
+	}
+	return ""
+}
+
+// FootprintData represents the volume profile of a candle
+type FootprintData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol        string            `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval      string            `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	OpenTime      int64             `protobuf:"varint,3,opt,name=open_time,json=openTime,proto3" json:"open_time,omitempty"`    // Unix timestamp in milliseconds
+	CloseTime     int64             `protobuf:"varint,4,opt,name=close_time,json=closeTime,proto3" json:"close_time,omitempty"` // Unix timestamp in milliseconds
+	TickSize      string            `protobuf:"bytes,5,opt,name=tick_size,json=tickSize,proto3" json:"tick_size,omitempty"`     // Price range of a level
+	Levels        []*FootprintLevel `protobuf:"bytes,6,rep,name=levels,proto3" json:"levels,omitempty"`                         // Levels with volume, by ascending price
+	Poc           string            `protobuf:"bytes,7,opt,name=poc,proto3" json:"poc,omitempty"`                               // Point of control, the level with the most volume
+	ValueAreaHigh string            `protobuf:"bytes,8,opt,name=value_area_high,json=valueAreaHigh,proto3" json:"value_area_high,omitempty"`
+	ValueAreaLow  string            `protobuf:"bytes,9,opt,name=value_area_low,json=valueAreaLow,proto3" json:"value_area_low,omitempty"`
+	IsClosed      bool              `protobuf:"varint,10,opt,name=is_closed,json=isClosed,proto3" json:"is_closed,omitempty"`
+}
+
+func (x *FootprintData) Reset() {
+	*x = FootprintData{}
+	mi := &file_proto_ohlc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FootprintData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FootprintData) ProtoMessage() {}
+
+func (x *FootprintData) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ohlc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FootprintData.ProtoReflect.Descriptor instead.
+func (*FootprintData) Descriptor() ([]byte, []int) {
+	return file_proto_ohlc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FootprintData) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *FootprintData) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *FootprintData) GetOpenTime() int64 {
 	if x != nil {
 		return x.OpenTime
 	}
 	return 0
 }
 
-func (x *OHLCData) GetCloseTime() int64 {
+func (x *FootprintData) GetCloseTime() int64 {
 	if x != nil {
 		return x.CloseTime
 	}
 	return 0
 }
 
+func (x *FootprintData) GetTickSize() string {
+	if x != nil {
+		return x.TickSize
+	}
+	return ""
+}
+
+func (x *FootprintData) GetLevels() []*FootprintLevel {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+func (x *FootprintData) GetPoc() string {
+	if x != nil {
+		return x.Poc
+	}
+	return ""
+}
+
+func (x *FootprintData) GetValueAreaHigh() string {
+	if x != nil {
+		return x.ValueAreaHigh
+	}
+	return ""
+}
+
+func (x *FootprintData) GetValueAreaLow() string {
+	if x != nil {
+		return x.ValueAreaLow
+	}
+	return ""
+}
+
+func (x *FootprintData) GetIsClosed() bool {
+	if x != nil {
+		return x.IsClosed
+	}
+	return false
+}
+
 var File_proto_ohlc_proto protoreflect.FileDescriptor
 
 var file_proto_ohlc_proto_rawDesc = []byte{
 	0x0a, 0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x04, 0x6f, 0x68, 0x6c, 0x63, 0x22, 0x2c, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73,
+	0x74, 0x6f, 0x12, 0x04, 0x6f, 0x68, 0x6c, 0x63, 0x22, 0x4a, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73,
 	0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
 	0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73,
-	0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x22, 0xc6, 0x01, 0x0a, 0x08, 0x4f, 0x48, 0x4c, 0x43, 0x44,
-	0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x6f,
-	0x70, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x12,
-	0x12, 0x0a, 0x04, 0x68, 0x69, 0x67, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x68,
-	0x69, 0x67, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x6c, 0x6f, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
-	0x52, 0x03, 0x6c, 0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x18, 0x05,
-	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76,
-	0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x76, 0x6f, 0x6c,
-	0x75, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65,
-	0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x6e, 0x54, 0x69, 0x6d, 0x65,
-	0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x32,
-	0x47, 0x0a, 0x0b, 0x4f, 0x48, 0x4c, 0x43, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x38,
-	0x0a, 0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x48, 0x4c, 0x43, 0x12, 0x16, 0x2e, 0x6f,
-	0x68, 0x6c, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x4f, 0x48, 0x4c, 0x43,
-	0x44, 0x61, 0x74, 0x61, 0x22, 0x00, 0x30, 0x01, 0x42, 0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x7a, 0x61, 0x6e, 0x69, 0x75, 0x6d, 0x2f, 0x6f,
-	0x68, 0x6c, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
+	0x61, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x73, 0x22, 0x44, 0x0a, 0x0e, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x1a,
+	0x0a, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0xd7, 0x05, 0x0a, 0x08, 0x4f,
+	0x48, 0x4c, 0x43, 0x44, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12,
+	0x1b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x79, 0x6e, 0x74, 0x68,
+	0x65, 0x74, 0x69, 0x63, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x79, 0x6e, 0x74,
+	0x68, 0x65, 0x74, 0x69, 0x63, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x63, 0x6c, 0x6f, 0x73,
+	0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x43, 0x6c, 0x6f, 0x73,
+	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x72, 0x61, 0x64, 0x65, 0x73, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x06, 0x74, 0x72, 0x61, 0x64, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x66, 0x69,
+	0x72, 0x73, 0x74, 0x5f, 0x74, 0x72, 0x61, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x11, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0c, 0x66, 0x69, 0x72, 0x73, 0x74, 0x54, 0x72, 0x61, 0x64, 0x65, 0x49, 0x64,
+	0x12, 0x22, 0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x74, 0x72, 0x61, 0x64, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x12, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x54, 0x72, 0x61,
+	0x64, 0x65, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x13, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6f, 0x70, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x69, 0x67, 0x68, 0x18, 0x15, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x68, 0x69, 0x67, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x6c, 0x6f, 0x77, 0x18,
+	0x16, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6c, 0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c,
+	0x6f, 0x73, 0x65, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x71, 0x75, 0x6f, 0x74,
+	0x65, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x71, 0x75, 0x6f, 0x74, 0x65, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x10, 0x74,
+	0x61, 0x6b, 0x65, 0x72, 0x5f, 0x62, 0x75, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18,
+	0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x42, 0x75, 0x79, 0x56,
+	0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x33, 0x0a, 0x16, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x5f, 0x62,
+	0x75, 0x79, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18,
+	0x1b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x42, 0x75, 0x79, 0x51,
+	0x75, 0x6f, 0x74, 0x65, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x77,
+	0x61, 0x70, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x77, 0x61, 0x70, 0x12, 0x19,
+	0x0a, 0x08, 0x62, 0x61, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x62, 0x61, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x3e, 0x0a, 0x0a, 0x69, 0x6e, 0x64,
+	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x1e, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x4f, 0x48, 0x4c, 0x43, 0x44, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x6e,
+	0x64, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x69,
+	0x6e, 0x64, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x1a, 0x3d, 0x0a, 0x0f, 0x49, 0x6e, 0x64,
+	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x4a, 0x04, 0x08, 0x02, 0x10, 0x07, 0x4a, 0x04,
+	0x08, 0x0c, 0x10, 0x10, 0x22, 0x66, 0x0a, 0x0e, 0x46, 0x6f, 0x6f, 0x74, 0x70, 0x72, 0x69, 0x6e,
+	0x74, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x62, 0x75, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x62, 0x75, 0x79, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73,
+	0x65, 0x6c, 0x6c, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x22, 0xc7, 0x02, 0x0a,
+	0x0d, 0x46, 0x6f, 0x6f, 0x74, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
+	0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
+	0x61, 0x6c, 0x12, 0x1b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x69, 0x63, 0x6b, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x69, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x2c, 0x0a, 0x06, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6f, 0x68,
+	0x6c, 0x63, 0x2e, 0x46, 0x6f, 0x6f, 0x74, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x52, 0x06, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x63,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x70, 0x6f, 0x63, 0x12, 0x26, 0x0a, 0x0f, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x5f, 0x61, 0x72, 0x65, 0x61, 0x5f, 0x68, 0x69, 0x67, 0x68, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x41, 0x72, 0x65, 0x61, 0x48,
+	0x69, 0x67, 0x68, 0x12, 0x24, 0x0a, 0x0e, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x5f, 0x61, 0x72, 0x65,
+	0x61, 0x5f, 0x6c, 0x6f, 0x77, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x41, 0x72, 0x65, 0x61, 0x4c, 0x6f, 0x77, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f,
+	0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73,
+	0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x32, 0xc5, 0x01, 0x0a, 0x0b, 0x4f, 0x48, 0x4c, 0x43, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x38, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x4f, 0x48, 0x4c, 0x43, 0x12, 0x16, 0x2e, 0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x6f,
+	0x68, 0x6c, 0x63, 0x2e, 0x4f, 0x48, 0x4c, 0x43, 0x44, 0x61, 0x74, 0x61, 0x22, 0x00, 0x30, 0x01,
+	0x12, 0x38, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x4f, 0x48,
+	0x4c, 0x43, 0x12, 0x14, 0x2e, 0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x6f, 0x68, 0x6c, 0x63, 0x2e,
+	0x4f, 0x48, 0x4c, 0x43, 0x44, 0x61, 0x74, 0x61, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0f, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x46, 0x6f, 0x6f, 0x74, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x2e,
+	0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x6f, 0x68, 0x6c, 0x63, 0x2e, 0x46, 0x6f, 0x6f,
+	0x74, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x44, 0x61, 0x74, 0x61, 0x22, 0x00, 0x30, 0x01, 0x42, 0x1f,
+	0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x7a, 0x61,
+	0x6e, 0x69, 0x75, 0x6d, 0x2f, 0x6f, 0x68, 0x6c, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -210,19 +631,29 @@ func file_proto_ohlc_proto_rawDescGZIP() []byte {
 	return file_proto_ohlc_proto_rawDescData
 }
 
-var file_proto_ohlc_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_ohlc_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_proto_ohlc_proto_goTypes = []any{
 	(*SubscribeRequest)(nil), // 0: ohlc.SubscribeRequest
-	(*OHLCData)(nil),         // 1: ohlc.OHLCData
+	(*CurrentRequest)(nil),   // 1: ohlc.CurrentRequest
+	(*OHLCData)(nil),         // 2: ohlc.OHLCData
+	(*FootprintLevel)(nil),   // 3: ohlc.FootprintLevel
+	(*FootprintData)(nil),    // 4: ohlc.FootprintData
+	nil,                      // 5: ohlc.OHLCData.IndicatorsEntry
 }
 var file_proto_ohlc_proto_depIdxs = []int32{
-	0, // 0: ohlc.OHLCService.StreamOHLC:input_type -> ohlc.SubscribeRequest
-	1, // 1: ohlc.OHLCService.StreamOHLC:output_type -> ohlc.OHLCData
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5, // 0: ohlc.OHLCData.indicators:type_name -> ohlc.OHLCData.IndicatorsEntry
+	3, // 1: ohlc.FootprintData.levels:type_name -> ohlc.FootprintLevel
+	0, // 2: ohlc.OHLCService.StreamOHLC:input_type -> ohlc.SubscribeRequest
+	1, // 3: ohlc.OHLCService.GetCurrentOHLC:input_type -> ohlc.CurrentRequest
+	0, // 4: ohlc.OHLCService.StreamFootprint:input_type -> ohlc.SubscribeRequest
+	2, // 5: ohlc.OHLCService.StreamOHLC:output_type -> ohlc.OHLCData
+	2, // 6: ohlc.OHLCService.GetCurrentOHLC:output_type -> ohlc.OHLCData
+	4, // 7: ohlc.OHLCService.StreamFootprint:output_type -> ohlc.FootprintData
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proto_ohlc_proto_init() }
@@ -236,7 +667,7 @@ func file_proto_ohlc_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_ohlc_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,15 +19,23 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	OHLCService_StreamOHLC_FullMethodName = "/ohlc.OHLCService/StreamOHLC"
+	OHLCService_StreamOHLC_FullMethodName      = "/ohlc.OHLCService/StreamOHLC"
+	OHLCService_GetCurrentOHLC_FullMethodName  = "/ohlc.OHLCService/GetCurrentOHLC"
+	OHLCService_StreamFootprint_FullMethodName = "/ohlc.OHLCService/StreamFootprint"
 )
 
 // OHLCServiceClient is the client API for OHLCService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type OHLCServiceClient interface {
-	// StreamOHLC streams real-time OHLC updates for requested symbols
+	// StreamOHLC streams real-time OHLC updates for requested symbols: throttled in-progress
+	// updates of each candle followed by a final closed message
 	StreamOHLC(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (OHLCService_StreamOHLCClient, error)
+	// GetCurrentOHLC returns the in-progress candle for a symbol and interval
+	GetCurrentOHLC(ctx context.Context, in *CurrentRequest, opts ...grpc.CallOption) (*OHLCData, error)
+	// StreamFootprint streams the footprints of the configured footprint intervals for requested
+	// symbols: throttled in-progress updates followed by a final closed message
+	StreamFootprint(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (OHLCService_StreamFootprintClient, error)
 }
 
 type oHLCServiceClient struct {
@@ -70,12 +78,59 @@ func (x *oHLCServiceStreamOHLCClient) Recv() (*OHLCData, error) {
 	return m, nil
 }
 
+func (c *oHLCServiceClient) GetCurrentOHLC(ctx context.Context, in *CurrentRequest, opts ...grpc.CallOption) (*OHLCData, error) {
+	out := new(OHLCData)
+	err := c.cc.Invoke(ctx, OHLCService_GetCurrentOHLC_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oHLCServiceClient) StreamFootprint(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (OHLCService_StreamFootprintClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OHLCService_ServiceDesc.Streams[1], OHLCService_StreamFootprint_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &oHLCServiceStreamFootprintClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OHLCService_StreamFootprintClient interface {
+	Recv() (*FootprintData, error)
+	grpc.ClientStream
+}
+
+type oHLCServiceStreamFootprintClient struct {
+	grpc.ClientStream
+}
+
+func (x *oHLCServiceStreamFootprintClient) Recv() (*FootprintData, error) {
+	m := new(FootprintData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // OHLCServiceServer is the server API for OHLCService service.
 // All implementations must embed UnimplementedOHLCServiceServer
 // for forward compatibility
 type OHLCServiceServer interface {
-	// StreamOHLC streams real-time OHLC updates for requested symbols
+	// StreamOHLC streams real-time OHLC updates for requested symbols: throttled in-progress
+	// updates of each candle followed by a final closed message
 	StreamOHLC(*SubscribeRequest, OHLCService_StreamOHLCServer) error
+	// GetCurrentOHLC returns the in-progress candle for a symbol and interval
+	GetCurrentOHLC(context.Context, *CurrentRequest) (*OHLCData, error)
+	// StreamFootprint streams the footprints of the configured footprint intervals for requested
+	// symbols: throttled in-progress updates followed by a final closed message
+	StreamFootprint(*SubscribeRequest, OHLCService_StreamFootprintServer) error
 	mustEmbedUnimplementedOHLCServiceServer()
 }
 
@@ -86,6 +141,12 @@ type UnimplementedOHLCServiceServer struct {
 func (UnimplementedOHLCServiceServer) StreamOHLC(*SubscribeRequest, OHLCService_StreamOHLCServer) error {
 	return status.Errorf(codes.Unimplemented, "method StreamOHLC not implemented")
 }
+func (UnimplementedOHLCServiceServer) GetCurrentOHLC(context.Context, *CurrentRequest) (*OHLCData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentOHLC not implemented")
+}
+func (UnimplementedOHLCServiceServer) StreamFootprint(*SubscribeRequest, OHLCService_StreamFootprintServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFootprint not implemented")
+}
 func (UnimplementedOHLCServiceServer) mustEmbedUnimplementedOHLCServiceServer() {}
 
 // UnsafeOHLCServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -120,19 +181,68 @@ func (x *oHLCServiceStreamOHLCServer) Send(m *OHLCData) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _OHLCService_GetCurrentOHLC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OHLCServiceServer).GetCurrentOHLC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OHLCService_GetCurrentOHLC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OHLCServiceServer).GetCurrentOHLC(ctx, req.(*CurrentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OHLCService_StreamFootprint_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OHLCServiceServer).StreamFootprint(m, &oHLCServiceStreamFootprintServer{stream})
+}
+
+type OHLCService_StreamFootprintServer interface {
+	Send(*FootprintData) error
+	grpc.ServerStream
+}
+
+type oHLCServiceStreamFootprintServer struct {
+	grpc.ServerStream
+}
+
+func (x *oHLCServiceStreamFootprintServer) Send(m *FootprintData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // OHLCService_ServiceDesc is the grpc.ServiceDesc for OHLCService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var OHLCService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "ohlc.OHLCService",
 	HandlerType: (*OHLCServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrentOHLC",
+			Handler:    _OHLCService_GetCurrentOHLC_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "StreamOHLC",
 			Handler:       _OHLCService_StreamOHLC_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamFootprint",
+			Handler:       _OHLCService_StreamFootprint_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/ohlc.proto",
 }
@@ -0,0 +1,283 @@
+// Package retention removes ticks and candles once they are older than their retention period
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+)
+
+// Config configures the retention of ticks and candles
+type Config struct {
+	Interval     time.Duration                // time between runs, zero disables the job
+	TickDays     int                          // whole days of ticks kept, zero keeps ticks forever
+	ArchiveDir   string                       // directory expired ticks are archived to as gzipped CSV before deletion, empty deletes them outright
+	CandleMonths map[candlestick.Interval]int // months of candles kept per interval, intervals not listed are kept forever
+	DryRun       bool                         // only count and log what would be removed
+}
+
+// Stats holds counters about the retention job
+type Stats struct {
+	Runs           int64     `json:"runs"`
+	Failures       int64     `json:"failures"`
+	TicksExpired   int64     `json:"ticks_expired"`   // ticks past their retention at the start of the last run
+	TicksArchived  int64     `json:"ticks_archived"`  // ticks archived before deletion
+	TicksDeleted   int64     `json:"ticks_deleted"`   // ticks removed
+	CandlesExpired int64     `json:"candles_expired"` // candles past their retention at the start of the last run
+	CandlesDeleted int64     `json:"candles_deleted"` // candles removed
+	LastRun        time.Time `json:"last_run"`
+	DryRun         bool      `json:"dry_run"`
+}
+
+// Job periodically removes the ticks and candles past their retention
+type Job struct {
+	storage candlestick.RetentionStorage
+	clock   candlestick.Clock
+	config  Config
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewJob creates a retention job removing data from storage once Run is called
+func NewJob(storage candlestick.RetentionStorage, clock candlestick.Clock, config Config) *Job {
+	return &Job{
+		storage: storage,
+		clock:   clock,
+		config:  config,
+		stats:   Stats{DryRun: config.DryRun},
+	}
+}
+
+// Run applies the retention policies on start and then every Interval until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("Error applying retention: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.clock.After(j.config.Interval):
+		}
+	}
+}
+
+// RunOnce applies the retention policies once
+func (j *Job) RunOnce(ctx context.Context) error {
+	now := j.clock.Now().UTC()
+
+	err := j.expireTicks(ctx, now)
+	if err == nil {
+		err = j.expireCandles(now)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stats.Runs++
+	j.stats.LastRun = now
+	if err != nil {
+		j.stats.Failures++
+	}
+	return err
+}
+
+// Stats returns counters about the retention job
+func (j *Job) Stats() Stats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+// add updates a counter of the stats
+func (j *Job) add(counter *int64, n int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	*counter += n
+}
+
+// set replaces a counter of the stats
+func (j *Job) set(counter *int64, n int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	*counter = n
+}
+
+// expireTicks archives and removes the ticks older than TickDays whole days, one day at a time
+func (j *Job) expireTicks(ctx context.Context, now time.Time) error {
+	if j.config.TickDays <= 0 {
+		return nil
+	}
+
+	cutoff := startOfDay(now.AddDate(0, 0, -j.config.TickDays))
+	expired, err := j.storage.CountTicks(cutoff)
+	if err != nil {
+		return err
+	}
+	j.set(&j.stats.TicksExpired, expired)
+	if expired == 0 {
+		return nil
+	}
+	if j.config.DryRun {
+		log.Printf("Retention dry run: would remove %d ticks before %s", expired, cutoff.Format(time.RFC3339))
+		return nil
+	}
+
+	oldest, err := j.storage.OldestTick()
+	if err != nil {
+		return err
+	}
+	for day := startOfDay(oldest.UTC()); day.Before(cutoff); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if j.config.ArchiveDir != "" {
+			archived, err := j.archive(day)
+			if err != nil {
+				return err
+			}
+			j.add(&j.stats.TicksArchived, archived)
+		}
+
+		// Deleting an hour at a time keeps each statement short on busy tables
+		for hour := day.Add(time.Hour); !hour.After(day.AddDate(0, 0, 1)); hour = hour.Add(time.Hour) {
+			deleted, err := j.storage.DeleteTicks(hour)
+			if err != nil {
+				return err
+			}
+			j.add(&j.stats.TicksDeleted, deleted)
+		}
+		log.Printf("Removed ticks of %s", day.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// archive writes the ticks of a day to a gzipped CSV file in ArchiveDir, returning how many were
+// written. The file only appears once complete, so a failed run leaves no partial archive. An
+// archive of the day left by a run that failed while deleting its ticks is never overwritten; the
+// remaining ticks go to ticks-<day>.1.csv.gz, ticks-<day>.2.csv.gz and so on instead
+func (j *Job) archive(day time.Time) (int64, error) {
+	name := "ticks-" + day.Format(time.DateOnly)
+	file, err := os.CreateTemp(j.config.ArchiveDir, name+".csv.gz.*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tick archive: %v", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	w := csv.NewWriter(gz)
+	w.Write([]string{"symbol", "price", "quantity", "timestamp", "trade_id", "first_trade_id", "last_trade_id", "is_buyer_maker"})
+
+	var archived int64
+	for hour := day; hour.Before(day.AddDate(0, 0, 1)); hour = hour.Add(time.Hour) {
+		ticks, err := j.storage.GetAllTicks(hour, hour.Add(time.Hour))
+		if err != nil {
+			return 0, err
+		}
+		for _, tick := range ticks {
+			w.Write([]string{
+				string(tick.Symbol),
+				tick.Price.String(),
+				tick.Quantity.String(),
+				tick.Timestamp.UTC().Format(time.RFC3339Nano),
+				strconv.FormatInt(tick.TradeID, 10),
+				strconv.FormatInt(tick.FirstTradeID, 10),
+				strconv.FormatInt(tick.LastTradeID, 10),
+				strconv.FormatBool(tick.IsBuyerMaker),
+			})
+		}
+		archived += int64(len(ticks))
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write tick archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write tick archive: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to write tick archive: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write tick archive: %v", err)
+	}
+	path, err := publish(file.Name(), filepath.Join(j.config.ArchiveDir, name))
+	if err != nil {
+		return 0, fmt.Errorf("failed to write tick archive: %v", err)
+	}
+
+	log.Printf("Archived %d ticks of %s to %s", archived, day.Format(time.DateOnly), path)
+	return archived, nil
+}
+
+// publish links a written archive to the first free name of base.csv.gz, base.1.csv.gz and so on,
+// failing rather than replacing an existing archive, and returns its path
+func publish(temp, base string) (string, error) {
+	for n := 0; ; n++ {
+		path := base + ".csv.gz"
+		if n > 0 {
+			path = fmt.Sprintf("%s.%d.csv.gz", base, n)
+		}
+
+		err := os.Link(temp, path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+// expireCandles removes the candles of each interval with a retention that are older than it
+func (j *Job) expireCandles(now time.Time) error {
+	var expired int64
+	for interval, months := range j.config.CandleMonths {
+		if months <= 0 {
+			continue
+		}
+
+		cutoff := now.AddDate(0, -months, 0)
+		count, err := j.storage.CountCandles(interval, cutoff)
+		if err != nil {
+			return err
+		}
+		expired += count
+		if count == 0 {
+			continue
+		}
+		if j.config.DryRun {
+			log.Printf("Retention dry run: would remove %d %s candles before %s", count, interval, cutoff.Format(time.RFC3339))
+			continue
+		}
+
+		deleted, err := j.storage.DeleteCandles(interval, cutoff)
+		if err != nil {
+			return err
+		}
+		j.add(&j.stats.CandlesDeleted, deleted)
+		log.Printf("Removed %d %s candles before %s", deleted, interval, cutoff.Format(time.RFC3339))
+	}
+	j.set(&j.stats.CandlesExpired, expired)
+	return nil
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,179 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/shopspring/decimal"
+)
+
+// newStorage returns a mock storage with a tick every 12 hours and a 1m and 1h candle every day
+// of the 100 days before now
+func newStorage(t *testing.T, now time.Time) candlestick.Storage {
+	storage := candlestick.NewMockStorage()
+	for day := 1; day <= 100; day++ {
+		openTime := now.AddDate(0, 0, -day)
+		for i := int64(0); i < 2; i++ {
+			tick := &candlestick.Tick{
+				Symbol:    "BTCUSDT",
+				Price:     decimal.NewFromInt(100),
+				Quantity:  decimal.NewFromInt(1),
+				Timestamp: openTime.Add(time.Duration(i) * 12 * time.Hour),
+				TradeID:   int64(day)*2 + i,
+			}
+			if err := storage.StoreTicks([]*candlestick.Tick{tick}); err != nil {
+				t.Fatalf("Failed to store tick: %v", err)
+			}
+		}
+		for _, interval := range []candlestick.Interval{candlestick.Interval1m, candlestick.Interval1h} {
+			if err := storage.Store(&candlestick.OHLC{Symbol: "BTCUSDT", Interval: interval, OpenTime: openTime}); err != nil {
+				t.Fatalf("Failed to store candle: %v", err)
+			}
+		}
+	}
+	return storage
+}
+
+func TestRunOnce(t *testing.T) {
+	now := time.Date(2025, 6, 15, 6, 0, 0, 0, time.UTC)
+	storage := newStorage(t, now)
+	retention := storage.(candlestick.RetentionStorage)
+	dir := t.TempDir()
+
+	job := NewJob(retention, candlestick.NewMockClock(now), Config{
+		TickDays:     30,
+		ArchiveDir:   dir,
+		CandleMonths: map[candlestick.Interval]int{candlestick.Interval1m: 2},
+	})
+	if err := job.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+
+	// Ticks before midnight 30 days ago are gone, those of the day 30 days ago at 06:00 are kept
+	cutoff := time.Date(2025, 5, 16, 0, 0, 0, 0, time.UTC)
+	if count, _ := retention.CountTicks(cutoff); count != 0 {
+		t.Errorf("Expected no ticks before %v, got %d", cutoff, count)
+	}
+	if count, _ := retention.CountTicks(now); count != 60 {
+		t.Errorf("Expected 60 ticks of the last 30 days, got %d", count)
+	}
+
+	// 1m candles are kept for 2 months, 1h candles forever
+	if count, _ := retention.CountCandles(candlestick.Interval1m, now); count != 61 {
+		t.Errorf("Expected 61 1m candles since April 15, got %d", count)
+	}
+	if count, _ := retention.CountCandles(candlestick.Interval1h, now); count != 100 {
+		t.Errorf("Expected 100 1h candles, got %d", count)
+	}
+
+	stats := job.Stats()
+	if stats.Runs != 1 || stats.TicksExpired != 140 || stats.TicksDeleted != 140 || stats.TicksArchived != 140 ||
+		stats.CandlesExpired != 39 || stats.CandlesDeleted != 39 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Each removed day is archived with both of its ticks
+	archives, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(archives) != 70 {
+		t.Fatalf("Expected 70 daily archives, got %d", len(archives))
+	}
+	file, err := os.Open(filepath.Join(dir, "ticks-2025-05-15.csv.gz"))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	records, err := csv.NewReader(gz).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "symbol" || records[1][3] != "2025-05-15T06:00:00Z" || records[2][3] != "2025-05-15T18:00:00Z" {
+		t.Errorf("Unexpected archive %v", records)
+	}
+}
+
+// failingDeletes fails to delete ticks before a time, like a run interrupted while deleting a day
+type failingDeletes struct {
+	candlestick.RetentionStorage
+	failAfter time.Time
+}
+
+func (f *failingDeletes) DeleteTicks(before time.Time) (int64, error) {
+	if before.After(f.failAfter) {
+		return 0, errors.New("connection reset")
+	}
+	return f.RetentionStorage.DeleteTicks(before)
+}
+
+func TestRunOnceAfterPartialDelete(t *testing.T) {
+	now := time.Date(2025, 6, 15, 6, 0, 0, 0, time.UTC)
+	retention := newStorage(t, now).(candlestick.RetentionStorage)
+	dir := t.TempDir()
+	config := Config{TickDays: 30, ArchiveDir: dir}
+
+	// The first run archives the oldest day, then fails once its 06:00 tick is deleted
+	oldest := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	failing := &failingDeletes{RetentionStorage: retention, failAfter: oldest.Add(12 * time.Hour)}
+	if err := NewJob(failing, candlestick.NewMockClock(now), config).RunOnce(context.Background()); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+	if err := NewJob(retention, candlestick.NewMockClock(now), config).RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+
+	// The first archive still holds both ticks of the day, the rerun archived the one left
+	for name, expected := range map[string]int{"ticks-2025-03-07.csv.gz": 2, "ticks-2025-03-07.1.csv.gz": 1} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to open archive: %v", err)
+		}
+		defer file.Close()
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		records, err := csv.NewReader(gz).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		if len(records) != expected+1 {
+			t.Errorf("Expected %d ticks in %s, got %v", expected, name, records)
+		}
+	}
+}
+
+func TestRunOnceDryRun(t *testing.T) {
+	now := time.Date(2025, 6, 15, 6, 0, 0, 0, time.UTC)
+	storage := newStorage(t, now)
+	retention := storage.(candlestick.RetentionStorage)
+
+	job := NewJob(retention, candlestick.NewMockClock(now), Config{
+		TickDays:     30,
+		CandleMonths: map[candlestick.Interval]int{candlestick.Interval1m: 2},
+		DryRun:       true,
+	})
+	if err := job.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+
+	if count, _ := retention.CountTicks(now); count != 200 {
+		t.Errorf("Expected every tick to be kept, got %d", count)
+	}
+	if count, _ := retention.CountCandles(candlestick.Interval1m, now); count != 100 {
+		t.Errorf("Expected every 1m candle to be kept, got %d", count)
+	}
+	stats := job.Stats()
+	if !stats.DryRun || stats.TicksExpired != 140 || stats.TicksDeleted != 0 || stats.CandlesExpired != 39 || stats.CandlesDeleted != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
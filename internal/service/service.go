@@ -2,32 +2,63 @@ package service
 
 import (
 	"context"
+	"expvar"
 	"fmt"
+	"io"
 	"log"
+	"runtime"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/azanium/ohlc/internal/binance"
 	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/azanium/ohlc/internal/retention"
 	"github.com/azanium/ohlc/internal/storage"
 	"github.com/azanium/ohlc/internal/streaming"
 )
 
 // Config holds service configuration
 type Config struct {
-	Symbols        []candlestick.Symbol
-	Interval       time.Duration
-	MaxSubscribers int
-	ChannelSize    int
-	StorageDSN     string
+	Symbols          []candlestick.Symbol
+	Intervals        []candlestick.Interval
+	Rollups          []candlestick.Interval                       // intervals built from closed candles of the shortest interval instead of ticks
+	CloseDelay       time.Duration                                // grace period after a boundary before candles are closed
+	GapFill          bool                                         // emit flat candles for intervals without trades
+	UpdateInterval   time.Duration                                // minimum time between in-progress candle updates, zero disables them
+	AllowedLateness  time.Duration                                // how long closed candles still accept late ticks as revisions
+	Precisions       candlestick.Precisions                       // per-symbol price and quantity precision
+	Calendar         candlestick.Calendar                         // time zone and week start candles are aligned to
+	Bars             map[candlestick.Symbol][]candlestick.BarSpec // tick, volume, dollar, range and renko bars built per symbol
+	HeikinAshi       []candlestick.Interval                       // intervals whose closed candles are also published as Heikin-Ashi candles
+	Indicators       []candlestick.IndicatorSpec                  // technical indicators streamed with the candles of every series
+	LiveIndicators   bool                                         // also stream indicator values with in-progress candle updates
+	Footprint        candlestick.FootprintConfig                  // footprints built alongside the candles of some intervals
+	TickWriter       candlestick.TickWriterConfig                 // buffering and batching of tick persistence
+	Shards           int                                          // number of symbol shards aggregated in parallel, defaulting to the number of CPUs
+	SnapshotInterval time.Duration                                // how often in-progress candles are saved for warm restarts, zero saves only on Stop
+	Retention        retention.Config                             // removal of ticks and candles past their retention, disabled with a zero interval
+	Clock            candlestick.Clock
+	MaxSubscribers   int
+	ChannelSize      int
+	Storage          storage.Config // storage backend, its databases and schema migration
 }
 
 // Service coordinates the OHLC data processing pipeline
 type Service struct {
 	client     binance.BinanceClient
 	aggregator candlestick.Aggregator
+	rollup     *candlestick.Rollup
+	heikinAshi *candlestick.HeikinAshi
+	indicators *candlestick.IndicatorEngine
+	footprints *candlestick.ShardedFootprintAggregator // nil without footprint intervals
+	fpStorage  candlestick.FootprintStorage
+	tickWriter *candlestick.TickWriter
+	retention  *retention.Job // nil when retention is disabled
 	storage    candlestick.Storage
 	streamer   *streaming.Service
 	config     Config
+	closeMu    sync.RWMutex // read-held from closing candles until they are streamed, so in-progress updates never overtake them, and write-held flushing the rollup
 }
 
 // SetClient allows injecting a mock client for testing
@@ -40,23 +71,295 @@ func New(ctx context.Context, config Config) (*Service, error) {
 	// Create components
 	client := binance.NewClient(ctx)
 
-	storage, err := storage.NewPostgreSQLStorage(config.StorageDSN)
+	backend, err := storage.Open(config.Storage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize storage: %v", err)
 	}
 
-	aggregator := candlestick.NewAggregator(config.Interval, storage)
+	if config.Shards < 1 {
+		config.Shards = runtime.NumCPU()
+	}
+
+	aggregator := candlestick.NewShardedAggregator(candlestick.AggregatorConfig{
+		Intervals:       config.Intervals,
+		GapFill:         config.GapFill,
+		AllowedLateness: config.AllowedLateness,
+		Precisions:      config.Precisions,
+		Calendar:        config.Calendar,
+		Bars:            config.Bars,
+	}, config.Shards)
+
+	// Fill holes in ranges read back from storage as well. Restoring reads what the service wrote
+	// before it stopped, which a follower may not have caught up with yet
+	var ohlcStorage candlestick.Storage = backend
+	var restoreStorage candlestick.Storage = storage.ReadYourWrites(backend)
+	if config.GapFill {
+		ohlcStorage = candlestick.NewGapFillStorage(ohlcStorage, config.Calendar)
+		restoreStorage = candlestick.NewGapFillStorage(restoreStorage, config.Calendar)
+	}
 
-	// Initialize streaming service
-	streamer := streaming.NewService(config.MaxSubscribers, config.ChannelSize)
+	if config.Clock == nil {
+		config.Clock = candlestick.NewSystemClock()
+	}
 
-	return &Service{
+	s := &Service{
 		client:     client,
 		aggregator: aggregator,
-		storage:    storage,
-		streamer:   streamer,
+		rollup:     newRollup(config),
+		heikinAshi: candlestick.NewHeikinAshi(config.HeikinAshi),
+		indicators: candlestick.NewIndicatorEngine(config.Indicators),
+		footprints: newFootprints(config),
+		storage:    ohlcStorage,
+		fpStorage:  backend,
+		tickWriter: candlestick.NewTickWriter(backend, config.Clock, config.TickWriter),
 		config:     config,
-	}, nil
+	}
+	s.streamer = streaming.NewService(config.MaxSubscribers, config.ChannelSize, s, config.Precisions)
+	if config.Retention.Interval > 0 {
+		s.retention = retention.NewJob(backend, config.Clock, config.Retention)
+	}
+
+	publishMetrics(s)
+
+	// Pick up the candles that were in progress when the service last stopped
+	if err := s.restore(restoreStorage); err != nil {
+		log.Printf("Error restoring in-progress candles: %v", err)
+	}
+
+	return s, nil
+}
+
+// newRollup creates the rollup of the shortest interval into the configured rollup intervals, or
+// nil if there are none
+func newRollup(config Config) *candlestick.Rollup {
+	if len(config.Rollups) == 0 || len(config.Intervals) == 0 {
+		return nil
+	}
+
+	source := config.Intervals[0]
+	for _, interval := range config.Intervals {
+		if interval.Duration() < source.Duration() {
+			source = interval
+		}
+	}
+	return candlestick.NewRollup(source, config.Rollups, config.Precisions, config.Calendar, config.AllowedLateness)
+}
+
+// publishMetrics exposes the tick persistence, late tick and retention counters of s on
+// /debug/vars
+func publishMetrics(s *Service) {
+	if expvar.Get("tick_writer") != nil {
+		return
+	}
+	expvar.Publish("tick_writer", expvar.Func(func() any { return s.tickWriter.Stats() }))
+	expvar.Publish("aggregator", expvar.Func(func() any { return s.aggregator.Stats() }))
+	if s.retention != nil {
+		expvar.Publish("retention", expvar.Func(func() any { return s.retention.Stats() }))
+	}
+}
+
+// newFootprints creates the footprint aggregator of the configured footprint intervals, or nil if
+// there are none. Footprints close with their candles, so their intervals must be aggregated or
+// rolled up as well
+func newFootprints(config Config) *candlestick.ShardedFootprintAggregator {
+	intervals := make([]candlestick.Interval, 0, len(config.Footprint.Intervals))
+	for _, interval := range config.Footprint.Intervals {
+		if !slices.Contains(config.Intervals, interval) && !slices.Contains(config.Rollups, interval) {
+			log.Printf("Ignoring footprint interval %s without candles", interval)
+			continue
+		}
+		intervals = append(intervals, interval)
+	}
+	if len(intervals) == 0 {
+		return nil
+	}
+
+	config.Footprint.Intervals = intervals
+	config.Footprint.Precisions = config.Precisions
+	config.Footprint.Calendar = config.Calendar
+	return candlestick.NewShardedFootprintAggregator(config.Footprint, config.Shards)
+}
+
+// restore rebuilds the in-progress candles from the last snapshot in store, then replays the ticks
+// stored since, so that candles spanning a restart match those of an uninterrupted run. Footprints
+// are not snapshotted, so their candles in progress are rebuilt from ticks alone
+func (s *Service) restore(store candlestick.Storage) error {
+	snapshot, err := store.LoadSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %v", err)
+	}
+	s.aggregator.Restore(snapshot)
+
+	now := s.config.Clock.Now()
+	var longest candlestick.Interval
+	for _, interval := range s.config.Intervals {
+		if interval.Duration() > longest.Duration() {
+			longest = interval
+		}
+	}
+	if longest == "" {
+		return nil
+	}
+
+	var footprintStart time.Time
+	if s.footprints != nil {
+		footprintStart = now
+		for _, interval := range s.config.Footprint.Intervals {
+			if start := s.config.Calendar.Truncate(interval, now); start.Before(footprintStart) {
+				footprintStart = start
+			}
+		}
+	}
+
+	for _, symbol := range s.config.Symbols {
+		start := s.replayStart(snapshot, symbol, longest, now)
+		from := start
+		if s.footprints != nil && footprintStart.Before(from) {
+			from = footprintStart
+		}
+
+		ticks, err := store.GetTicks(symbol, from, now)
+		if err != nil {
+			return fmt.Errorf("failed to load ticks for %s: %v", symbol, err)
+		}
+
+		// Candles completed while replaying were already published before the restart
+		for _, tick := range ticks {
+			if !tick.Timestamp.Before(start) {
+				s.aggregator.Replay(*tick)
+			}
+			if s.footprints != nil {
+				s.footprints.Process(*tick)
+			}
+		}
+		log.Printf("Replayed %d ticks for symbol=%s since %s", len(ticks), symbol, from.Format(time.RFC3339))
+	}
+
+	// Footprints of candles completed before the restart were already published
+	if s.footprints != nil {
+		s.footprints.Prune(now)
+	}
+
+	if s.rollup != nil {
+		if err := s.restoreRollup(store, now); err != nil {
+			return err
+		}
+	}
+	if err := s.restoreHeikinAshi(store, now); err != nil {
+		return err
+	}
+	return s.restoreIndicators(store, now)
+}
+
+// replayStart returns the time the ticks of symbol are replayed from: the last tick the snapshot
+// holds for it, less the allowed lateness of ticks arriving after the snapshot was saved, or without
+// any of its candles in the snapshot, the start of the longest interval's current candle
+func (s *Service) replayStart(snapshot []*candlestick.OHLC, symbol candlestick.Symbol, longest candlestick.Interval, now time.Time) time.Time {
+	var last time.Time
+	for _, ohlc := range snapshot {
+		if ohlc.Symbol != symbol {
+			continue
+		}
+
+		// Bars close at their last tick, time candles open at or before it
+		seen := ohlc.OpenTime
+		if ohlc.Interval.BarType() != candlestick.BarTypeTime {
+			seen = ohlc.CloseTime
+		}
+		if seen.After(last) {
+			last = seen
+		}
+	}
+
+	if last.IsZero() {
+		return s.config.Calendar.Truncate(longest, now)
+	}
+	return last.Add(-s.config.AllowedLateness)
+}
+
+// restoreRollup rebuilds the rolled up candles in progress from the stored source candles, added
+// once and in order from the start of the longest candle in progress
+func (s *Service) restoreRollup(store candlestick.Storage, now time.Time) error {
+	start := now
+	for _, interval := range s.rollup.Intervals() {
+		if truncated := s.config.Calendar.Truncate(interval, now); truncated.Before(start) {
+			start = truncated
+		}
+	}
+
+	for _, symbol := range s.config.Symbols {
+		candles, err := store.GetRange(symbol, s.rollup.Source(), start, now)
+		if err != nil {
+			return fmt.Errorf("failed to load %s candles for %s: %v", s.rollup.Source(), symbol, err)
+		}
+		for _, ohlc := range candles {
+			s.rollup.Add(ohlc)
+		}
+	}
+	return nil
+}
+
+// heikinAshiWarmup is the number of stored candles seeding each Heikin-Ashi series, after which
+// the influence of the unknown earlier candles on the open price is negligible
+const heikinAshiWarmup = 50
+
+// restoreHeikinAshi seeds the Heikin-Ashi series from the last stored candles of their intervals
+func (s *Service) restoreHeikinAshi(store candlestick.Storage, now time.Time) error {
+	for _, symbol := range s.config.Symbols {
+		for _, interval := range s.config.HeikinAshi {
+			end := s.config.Calendar.Truncate(interval, now)
+			start := end.Add(-heikinAshiWarmup * interval.Duration())
+			candles, err := store.GetRange(symbol, interval, start, end)
+			if err != nil {
+				return fmt.Errorf("failed to load %s candles for %s: %v", interval, symbol, err)
+			}
+			s.heikinAshi.Warm(candles)
+		}
+	}
+	return nil
+}
+
+// restoreIndicators warms the indicators of every time and Heikin-Ashi series up from their last
+// stored candles
+func (s *Service) restoreIndicators(store candlestick.Storage, now time.Time) error {
+	warmup := s.indicators.Warmup()
+	if warmup == 0 {
+		return nil
+	}
+
+	for _, symbol := range s.config.Symbols {
+		for _, interval := range slices.Concat(s.config.Intervals, s.config.Rollups) {
+			if err := s.warmIndicators(store, symbol, interval, interval, warmup, now); err != nil {
+				return err
+			}
+		}
+		for _, interval := range s.config.HeikinAshi {
+			if err := s.warmIndicators(store, symbol, candlestick.HeikinAshiInterval(interval), interval, warmup, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// warmIndicators feeds the last warmup closed candles of a series, whose candles span interval,
+// into its indicators
+func (s *Service) warmIndicators(store candlestick.Storage, symbol candlestick.Symbol, series, interval candlestick.Interval, warmup int, now time.Time) error {
+	end := s.config.Calendar.Truncate(interval, now)
+	start := end.Add(-time.Duration(warmup) * interval.Duration())
+	candles, err := store.GetRange(symbol, series, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to load %s candles for %s: %v", series, symbol, err)
+	}
+	s.indicators.Warm(candles)
+	return nil
+}
+
+// saveSnapshot saves the in-progress candles so they survive a restart
+func (s *Service) saveSnapshot() {
+	if err := s.storage.SaveSnapshot(s.aggregator.Snapshot()); err != nil {
+		log.Printf("Error saving snapshot: %v", err)
+	}
 }
 
 // Start begins the OHLC data processing
@@ -66,52 +369,251 @@ func (s *Service) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to connect to Binance: %v", err)
 	}
 
-	// Create tick channel
-	tickCh := make(chan candlestick.Tick, 1000)
+	// Persist ticks in the background, off the aggregation path
+	go s.tickWriter.Run(ctx)
+
+	// Each shard of symbols gets its own tick channel and goroutine, keeping ticks of a symbol in
+	// order while shards are processed in parallel
+	tickChs := make([]chan candlestick.Tick, s.config.Shards)
+	for i := range tickChs {
+		tickChs[i] = make(chan candlestick.Tick, 1000)
+		go s.processTicks(ctx, tickChs[i])
+	}
 
 	// Subscribe to ticks
 	for _, symbol := range s.config.Symbols {
-		s.client.Subscribe(symbol, tickCh)
+		s.client.Subscribe(symbol, tickChs[candlestick.ShardOf(symbol, s.config.Shards)])
 	}
 
-	// Process ticks
+	// Close candles on their boundaries even when no further ticks arrive
+	flushers := []candlestick.Flusher{s.aggregator}
+	if s.rollup != nil {
+		flushers = append(flushers, s.rollup)
+	}
+	closer := candlestick.NewCloser(s.config.Clock, s.config.Calendar, slices.Concat(s.config.Intervals, s.config.Rollups), s.config.CloseDelay, flushers...)
+	closer.SetLocker(s.closeMu.RLocker())
+	if s.rollup != nil {
+		// Source candles closed by ticks at the same boundary are rolled up before the rollup is
+		// flushed, or the candles they belong to would complete without them
+		closer.SetFlusherLocker(s.rollup, &s.closeMu)
+	}
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("Recovered from panic in tick processing: %v", r)
+				log.Printf("Recovered from panic in candle closer: %v", r)
 			}
 		}()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case tick := <-tickCh:
-				// Process tick and get completed OHLC if available
-				ohlc, err := s.aggregator.Process(tick)
-				if err != nil {
-					log.Printf("Error processing tick: %v", err)
-					continue
+		closer.Run(ctx, s.publish)
+	}()
+
+	// Stream conflated in-progress candle updates at the configured rate
+	if s.config.UpdateInterval > 0 {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Recovered from panic in update streaming: %v", r)
 				}
+			}()
+			s.streamUpdates(ctx)
+		}()
+	}
 
-				// If we have a completed OHLC, store and stream it
-				if ohlc != nil {
-					// Store OHLC
-					if err := s.storage.Store(ohlc); err != nil {
-						log.Printf("Error storing OHLC: %v", err)
-					}
-
-					// Stream OHLC
-					if err := s.streamer.Stream(ohlc); err != nil {
-						log.Printf("Error streaming OHLC: %v", err)
-					}
+	// Periodically save in-progress candles, bounding what a crash loses to the ticks table
+	if s.config.SnapshotInterval > 0 {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Recovered from panic in snapshotting: %v", r)
 				}
+			}()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.config.Clock.After(s.config.SnapshotInterval):
+					s.saveSnapshot()
+				}
+			}
+		}()
+	}
+
+	// Remove ticks and candles past their retention in the background
+	if s.retention != nil {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Recovered from panic in retention: %v", r)
+				}
+			}()
+			s.retention.Run(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// streamUpdates periodically streams the in-progress candles that changed since the last update
+func (s *Service) streamUpdates(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.config.Clock.After(s.config.UpdateInterval):
+		}
+
+		s.closeMu.Lock()
+		updates := s.aggregator.Updates()
+		if s.rollup != nil {
+			updates = append(updates, s.rollupUpdates(updates)...)
+		}
+		for _, ohlc := range updates {
+			if ha := s.heikinAshi.Current(ohlc); ha != nil {
+				updates = append(updates, ha)
+			}
+		}
+		for _, ohlc := range updates {
+			if s.config.LiveIndicators {
+				ohlc = s.indicators.Apply(ohlc)
+			}
+			if err := s.streamer.Stream(ohlc); err != nil {
+				log.Printf("Error streaming OHLC update: %v", err)
+			}
+		}
+		if s.footprints != nil {
+			for _, footprint := range s.footprints.Updates() {
+				if err := s.streamer.StreamFootprintUpdate(footprint); err != nil {
+					log.Printf("Error streaming footprint update: %v", err)
+				}
+			}
+		}
+		s.closeMu.Unlock()
+	}
+}
+
+// rollupUpdates returns the rolled up candles in progress changed by the source candles rolled up
+// since the previous call or by the source candles in progress among updates
+func (s *Service) rollupUpdates(updates []*candlestick.OHLC) []*candlestick.OHLC {
+	symbols := s.rollup.Updated()
+	for _, ohlc := range updates {
+		if ohlc.Interval == s.rollup.Source() && !slices.Contains(symbols, ohlc.Symbol) {
+			symbols = append(symbols, ohlc.Symbol)
+		}
+	}
+
+	var rolled []*candlestick.OHLC
+	for _, symbol := range symbols {
+		for _, interval := range s.rollup.Intervals() {
+			if ohlc := s.Current(symbol, interval); ohlc != nil {
+				rolled = append(rolled, ohlc)
 			}
 		}
+	}
+	return rolled
+}
+
+// Current returns a copy of the in-progress OHLC for a symbol and series, aggregated from ticks,
+// rolled up or derived as Heikin-Ashi, or nil if there is none
+func (s *Service) Current(symbol candlestick.Symbol, interval candlestick.Interval) *candlestick.OHLC {
+	if source, ok := s.heikinAshi.Source(interval); ok {
+		return s.heikinAshi.Current(s.Current(symbol, source))
+	}
+	if s.rollup != nil && slices.Contains(s.rollup.Intervals(), interval) {
+		return s.rollup.Current(symbol, interval, s.aggregator.Current(symbol, s.rollup.Source()))
+	}
+	return s.aggregator.Current(symbol, interval)
+}
+
+// processTicks aggregates the ticks of one shard of symbols until ctx is cancelled
+func (s *Service) processTicks(ctx context.Context, tickCh <-chan candlestick.Tick) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in tick processing: %v", r)
+		}
 	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-tickCh:
+			// Queue the tick for storage; a full buffer drops the write, never the tick
+			s.tickWriter.Write(tick)
+			s.processTick(tick)
+		}
+	}
+}
 
-	return nil
+// processTick aggregates a tick and publishes the candles it completes before any update of the
+// candles it opens can be streamed
+func (s *Service) processTick(tick candlestick.Tick) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	if s.footprints != nil {
+		s.footprints.Process(tick)
+	}
+
+	// Process tick and get completed OHLCs if available
+	completed, err := s.aggregator.Process(tick)
+	if err != nil {
+		log.Printf("Error processing tick: %v", err)
+		return
+	}
+
+	// Store and stream every completed OHLC
+	for _, ohlc := range completed {
+		s.publish(ohlc)
+	}
 }
 
+// publish stores a completed OHLC and streams it to subscribers, called with closeMu read-held
+func (s *Service) publish(ohlc *candlestick.OHLC) {
+	// Advance the indicators of the candle's series
+	ohlc = s.indicators.Apply(ohlc)
+
+	// Store OHLC
+	if err := s.storage.Store(ohlc); err != nil {
+		log.Printf("Error storing OHLC: %v", err)
+	}
+
+	// Stream OHLC
+	if err := s.streamer.Stream(ohlc); err != nil {
+		log.Printf("Error streaming OHLC: %v", err)
+	}
+
+	// Complete the candle's footprint
+	if s.footprints != nil {
+		if footprint := s.footprints.Close(ohlc); footprint != nil {
+			s.publishFootprint(footprint)
+		}
+	}
+
+	// Roll the candle up into the higher intervals built from it
+	if s.rollup != nil {
+		for _, rolled := range s.rollup.Add(ohlc) {
+			s.publish(rolled)
+		}
+	}
+
+	// Derive the Heikin-Ashi candle of the closed candle
+	if ha := s.heikinAshi.Add(ohlc); ha != nil {
+		s.publish(ha)
+	}
+}
+
+// publishFootprint stores a closed footprint and streams it to footprint subscribers
+func (s *Service) publishFootprint(footprint *candlestick.Footprint) {
+	if err := s.fpStorage.StoreFootprint(footprint); err != nil {
+		log.Printf("Error storing footprint: %v", err)
+	}
+
+	if err := s.streamer.StreamFootprintUpdate(footprint); err != nil {
+		log.Printf("Error streaming footprint: %v", err)
+	}
+}
+
+// tickWriterShutdownTimeout bounds how long Stop waits for buffered ticks to be stored
+const tickWriterShutdownTimeout = 10 * time.Second
+
 // Stop gracefully shuts down the service
 func (s *Service) Stop() error {
 	// Close Binance connection
@@ -119,9 +621,21 @@ func (s *Service) Stop() error {
 		log.Printf("Error closing Binance client: %v", err)
 	}
 
+	// Store the buffered ticks, which Run does once the context is cancelled
+	select {
+	case <-s.tickWriter.Done():
+	case <-time.After(tickWriterShutdownTimeout):
+		log.Printf("Timed out storing buffered ticks: %+v", s.tickWriter.Stats())
+	}
+
+	// Save in-progress candles for the next start
+	s.saveSnapshot()
+
 	// Close storage
-	if err := s.storage.(*storage.PostgreSQLStorage).Close(); err != nil {
-		log.Printf("Error closing storage: %v", err)
+	if closer, ok := s.storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Error closing storage: %v", err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+)
+
+// Drivers selecting the storage backend
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Config selects a storage backend and configures it
+type Config struct {
+	Driver   string // DriverPostgres or DriverSQLite, empty for DriverPostgres
+	Postgres PostgreSQLConfig
+	SQLite   SQLiteConfig
+}
+
+// Backend stores candles, ticks and footprints and removes them once past their retention
+type Backend interface {
+	candlestick.Storage
+	candlestick.FootprintStorage
+	candlestick.RetentionStorage
+	io.Closer
+}
+
+// Open creates the storage backend selected by config
+func Open(config Config) (Backend, error) {
+	switch config.Driver {
+	case "", DriverPostgres:
+		return NewPostgreSQLStorage(config.Postgres)
+	case DriverSQLite:
+		return NewSQLiteStorage(config.SQLite)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q, expected %s or %s", config.Driver, DriverPostgres, DriverSQLite)
+	}
+}
+
+// ReadYourWrites returns a view of backend whose reads see every write it made, reading from the
+// master of a PostgreSQL backend rather than its follower. The view must not be closed
+func ReadYourWrites(backend Backend) Backend {
+	if postgres, ok := backend.(*PostgreSQLStorage); ok {
+		return postgres.Master()
+	}
+	return backend
+}
+
+// OpenMigrator creates a migrator of the database of the storage backend selected by config
+func OpenMigrator(config Config) (*Migrator, error) {
+	switch config.Driver {
+	case "", DriverPostgres:
+		return NewPostgreSQLMigrator(config.Postgres.MasterDSN)
+	case DriverSQLite:
+		return NewSQLiteMigrator(config.SQLite.Path)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q, expected %s or %s", config.Driver, DriverPostgres, DriverSQLite)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// PostgreSQLConfig configures the connections of a PostgreSQLStorage
+type PostgreSQLConfig struct {
+	MasterDSN       string        // database receiving every write
+	FollowerDSN     string        // replica serving candle, tick and footprint reads, empty to read from the master
+	MaxOpenConns    int           // most open connections per database, zero for no limit
+	MaxIdleConns    int           // most idle connections kept per database, zero for the database/sql default
+	ConnMaxLifetime time.Duration // longest a connection is reused, zero for no limit
+	MaxRetry        int           // retries of connecting, reads and idempotent writes failing on the connection rather than in the database
+	Migrate         bool          // apply pending schema migrations instead of failing on them
+}
+
+// retryDelay is the wait before the first retry of a failed operation, doubling for each further
+// retry
+const retryDelay = 100 * time.Millisecond
+
+// open connects to the database at dsn with the pool settings of config
+func open(dsn string, config PostgreSQLConfig) (*gorm.DB, error) {
+	var db *gorm.DB
+	err := retry(config.MaxRetry, func() error {
+		var err error
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	return db, nil
+}
+
+// retry runs op until it succeeds, fails with an error reported by the database, which retrying
+// would not change, or has been retried maxRetry times
+func retry(maxRetry int, op func() error) error {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := op()
+		var pgErr *pgconn.PgError
+		if err == nil || attempt >= maxRetry || errors.As(err, &pgErr) {
+			return err
+		}
+
+		log.Printf("Retrying database operation in %v after error: %v", delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// onFollower runs a query on the follower, falling back to the master if there is no follower or
+// the follower fails
+func (s *PostgreSQLStorage) onFollower(query func(db *gorm.DB) error) error {
+	if s.follower != nil {
+		err := retry(s.maxRetry, func() error { return query(s.follower) })
+		if err == nil {
+			return nil
+		}
+		log.Printf("Error reading from follower, falling back to master: %v", err)
+	}
+	return s.onMaster(query)
+}
+
+// onMaster runs an operation that is safe to repeat on the master
+func (s *PostgreSQLStorage) onMaster(op func(db *gorm.DB) error) error {
+	return retry(s.maxRetry, func() error { return op(s.db) })
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestRetry(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name     string
+		err      error
+		maxRetry int
+		attempts int
+	}{
+		{"success", nil, 2, 1},
+		{"connection error", errors.New("connection reset by peer"), 2, 3},
+		{"no retries", errors.New("connection reset by peer"), 0, 1},
+		{"database error", &pgconn.PgError{Code: "23505"}, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retry(tt.maxRetry, func() error {
+				attempts++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
+
+// TestPostgreSQLStorageFollowerFallback checks that reads fall back to the master once the
+// follower fails, using the database in OHLC_TEST_DSN as both
+func TestPostgreSQLStorageFollowerFallback(t *testing.T) {
+	dsn := os.Getenv("OHLC_TEST_DSN")
+	if dsn == "" {
+		t.Skip("OHLC_TEST_DSN is not set")
+	}
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	storage, err := NewPostgreSQLStorage(PostgreSQLConfig{MasterDSN: dsn, FollowerDSN: dsn + " application_name=follower", Migrate: true})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer storage.Close()
+	if storage.follower == nil {
+		t.Fatal("Expected a follower connection")
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := storage.GetRange("FOLLOWERUSDT", candlestick.Interval1m, start, start.Add(time.Hour)); err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+
+	follower, _ := storage.follower.DB()
+	follower.Close()
+	if _, err := storage.GetRange("FOLLOWERUSDT", candlestick.Interval1m, start, start.Add(time.Hour)); err != nil {
+		t.Errorf("Expected GetRange to fall back to the master, got %v", err)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	storage := &PostgreSQLStorage{db: &gorm.DB{}, follower: &gorm.DB{}}
+
+	// The view reads from the master, leaving the follower of the storage in place
+	master, ok := ReadYourWrites(storage).(*PostgreSQLStorage)
+	if !ok || master == storage || master.db != storage.db || master.follower != nil {
+		t.Errorf("Expected a view of the master, got %+v", master)
+	}
+	if storage.follower == nil {
+		t.Error("Expected the storage to keep its follower")
+	}
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the migrations of each dialect as <version>_<name>.up.sql and
+// <version>_<name>.down.sql files in a directory named after the dialect
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationsTable records the applied migrations
+const migrationsTable = "schema_migrations"
+
+// migrationLock is the PostgreSQL advisory lock key held while migrating, so that concurrent
+// migrations of several instances apply each migration once
+const migrationLock = 7061636
+
+// Migration is a versioned schema change and its reversal
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time // nil while pending
+}
+
+// LoadMigrations returns the embedded migrations of a dialect, e.g. "postgres", by ascending version
+func LoadMigrations(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, path.Join("migrations", dialect))
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %v", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		versionStr, title, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || !found || err != nil || version <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join("migrations", dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: title}
+			byVersion[version] = migration
+		} else if migration.Name != title {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, migration.Name, title)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts the embedded migrations of a database, each in a transaction that
+// also records it in schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator of a database with the embedded migrations of its dialect
+func NewMigrator(db *gorm.DB, dialect string) (*Migrator, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`, migrationsTable)).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", migrationsTable, err)
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest embedded migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the newest applied migration, or 0 if none were applied
+func (m *Migrator) Version() (int, error) {
+	var version int
+	err := m.db.Table(migrationsTable).Select("COALESCE(MAX(version), 0)").Row().Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the schema version: %v", err)
+	}
+	return version, nil
+}
+
+// Status returns every embedded migration and when it was applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var applied []struct {
+		Version   int
+		AppliedAt time.Time
+	}
+	if err := m.db.Table(migrationsTable).Select("version, applied_at").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", migrationsTable, err)
+	}
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = MigrationStatus{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+// Up applies the pending migrations up to and including version target, or all of them if target
+// is 0
+func (m *Migrator) Up(target int) error {
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if err := m.apply(migration, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down reverts the applied migrations newer than version target, newest first
+func (m *Migrator) Down(target int) error {
+	for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].Version > target; i-- {
+		if err := m.apply(m.migrations[i], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply runs the up or down script of a migration unless it is already applied or reverted
+func (m *Migrator) apply(migration Migration, up bool) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if m.dialect == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+				return err
+			}
+		}
+
+		var applied int64
+		if err := tx.Table(migrationsTable).Where("version = ?", migration.Version).Count(&applied).Error; err != nil {
+			return err
+		}
+		if (applied > 0) == up {
+			return nil
+		}
+
+		if up {
+			log.Printf("Applying migration %d %s", migration.Version, migration.Name)
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Exec(fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", migrationsTable),
+				migration.Version, migration.Name, time.Now().UTC()).Error
+		}
+
+		log.Printf("Reverting migration %d %s", migration.Version, migration.Name)
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE version = ?", migrationsTable), migration.Version).Error
+	})
+	if err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return &StorageError{Operation: fmt.Sprintf("migrate %s %d_%s", direction, migration.Version, migration.Name), Err: err}
+	}
+	return nil
+}
+
+// checkSchema applies the pending migrations of a database if migrate is set, and otherwise fails
+// if any are pending
+func checkSchema(db *gorm.DB, dialect string, migrate bool) error {
+	migrator, err := NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	if migrate {
+		return migrator.Up(0)
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	switch {
+	case version < migrator.Latest():
+		return fmt.Errorf("database schema version %d is behind %d, run `ohlc migrate up`", version, migrator.Latest())
+	case version > migrator.Latest():
+		log.Printf("Database schema version %d is ahead of version %d of this build", version, migrator.Latest())
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations("postgres")
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("Expected at least 2 migrations, got %d", len(migrations))
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Migration %d: expected version %d, got %d", i, i+1, migration.Version)
+		}
+		if migration.Name == "" || strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("Migration %d: expected a name and up and down scripts, got %+v", migration.Version, migration)
+		}
+	}
+	if migrations[0].Name != "baseline" || !strings.Contains(migrations[1].Up, "idx_ohlc_symbol_interval_open_time") {
+		t.Errorf("Unexpected migrations %s and %s", migrations[0].Name, migrations[1].Name)
+	}
+
+	if _, err := LoadMigrations("oracle"); err == nil {
+		t.Error("Expected an error for a dialect without migrations")
+	}
+}
+
+// TestMigratorRoundTrip reverts and reapplies the latest migration of the database in OHLC_TEST_DSN
+func TestMigratorRoundTrip(t *testing.T) {
+	dsn := os.Getenv("OHLC_TEST_DSN")
+	if dsn == "" {
+		t.Skip("OHLC_TEST_DSN is not set")
+	}
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	migrator, err := NewPostgreSQLMigrator(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if err := migrator.Up(0); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	latest := migrator.Latest()
+
+	if err := migrator.Down(latest - 1); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if version, err := migrator.Version(); err != nil || version != latest-1 {
+		t.Fatalf("Expected version %d after Down, got %d (%v)", latest-1, version, err)
+	}
+	if _, err := NewPostgreSQLStorage(PostgreSQLConfig{MasterDSN: dsn}); err == nil {
+		t.Error("Expected the storage to refuse a database with pending migrations")
+	}
+
+	if err := migrator.Up(0); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, migration := range status {
+		if migration.AppliedAt == nil {
+			t.Errorf("Migration %d %s is still pending", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+-- Baseline schema, safe to apply to databases created by earlier versions of db/schema.sql or by
+-- GORM's AutoMigrate: missing tables, columns and indexes are added and prices are converted to
+-- exact NUMERIC values
+CREATE TABLE IF NOT EXISTS ticks (
+    id uuid DEFAULT gen_random_uuid(),
+    symbol VARCHAR(255),
+    price NUMERIC,
+    quantity NUMERIC,
+    timestamp TIMESTAMP,
+    trade_id BIGINT,
+    first_trade_id BIGINT,
+    last_trade_id BIGINT,
+    is_buyer_maker BOOLEAN,
+    PRIMARY KEY (id)
+);
+
+ALTER TABLE ticks ALTER COLUMN price TYPE NUMERIC;
+ALTER TABLE ticks ALTER COLUMN quantity TYPE NUMERIC;
+ALTER TABLE ticks ADD COLUMN IF NOT EXISTS trade_id BIGINT;
+ALTER TABLE ticks ADD COLUMN IF NOT EXISTS first_trade_id BIGINT;
+ALTER TABLE ticks ADD COLUMN IF NOT EXISTS last_trade_id BIGINT;
+ALTER TABLE ticks ADD COLUMN IF NOT EXISTS is_buyer_maker BOOLEAN;
+
+CREATE INDEX IF NOT EXISTS idx_tick_symbol_timestamp_desc ON ticks(symbol, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_tick_timestamp ON ticks(timestamp);
+CREATE INDEX IF NOT EXISTS idx_tick_price ON ticks(price);
+CREATE INDEX IF NOT EXISTS idx_tick_quantity ON ticks(quantity);
+
+CREATE TABLE IF NOT EXISTS ohlcs (
+    id uuid DEFAULT gen_random_uuid(),
+    symbol VARCHAR(255),
+    interval VARCHAR(64),
+    bar_type VARCHAR(16) NOT NULL DEFAULT 'time',
+    open NUMERIC,
+    high NUMERIC,
+    low NUMERIC,
+    close NUMERIC,
+    volume NUMERIC,
+    quote_volume NUMERIC,
+    taker_buy_volume NUMERIC,
+    taker_buy_quote_volume NUMERIC,
+    vwap NUMERIC,
+    trades BIGINT,
+    first_trade_id BIGINT,
+    last_trade_id BIGINT,
+    open_time TIMESTAMP,
+    close_time TIMESTAMP,
+    synthetic BOOLEAN NOT NULL DEFAULT FALSE,
+    revision INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS interval VARCHAR(64);
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS bar_type VARCHAR(16) NOT NULL DEFAULT 'time';
+ALTER TABLE ohlcs ALTER COLUMN open TYPE NUMERIC;
+ALTER TABLE ohlcs ALTER COLUMN high TYPE NUMERIC;
+ALTER TABLE ohlcs ALTER COLUMN low TYPE NUMERIC;
+ALTER TABLE ohlcs ALTER COLUMN close TYPE NUMERIC;
+ALTER TABLE ohlcs ALTER COLUMN volume TYPE NUMERIC;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS quote_volume NUMERIC;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS taker_buy_volume NUMERIC;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS taker_buy_quote_volume NUMERIC;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS vwap NUMERIC;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS trades BIGINT;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS first_trade_id BIGINT;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS last_trade_id BIGINT;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS synthetic BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ohlcs ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 0;
+
+-- Superseded by the index on symbol, interval and open time
+DROP INDEX IF EXISTS idx_ohlc_symbol_open_time_desc;
+CREATE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time_desc ON ohlcs(symbol, interval, open_time DESC);
+CREATE INDEX IF NOT EXISTS idx_ohlc_close_time ON ohlcs(close_time);
+CREATE INDEX IF NOT EXISTS idx_ohlc_volume ON ohlcs(volume);
+
+-- In-progress candles saved on shutdown and periodically, restored on start
+CREATE TABLE IF NOT EXISTS ohlc_snapshots (LIKE ohlcs INCLUDING DEFAULTS);
+
+-- Volume profile of each closed candle, with its price levels as a JSON array of
+-- {price, buy_volume, sell_volume} objects
+CREATE TABLE IF NOT EXISTS footprints (
+    id uuid DEFAULT gen_random_uuid(),
+    symbol VARCHAR(255),
+    interval VARCHAR(64),
+    open_time TIMESTAMP,
+    close_time TIMESTAMP,
+    tick_size NUMERIC,
+    levels JSONB,
+    poc NUMERIC,
+    value_area_high NUMERIC,
+    value_area_low NUMERIC,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_footprint_symbol_interval_open_time_desc ON footprints(symbol, interval, open_time DESC);
@@ -0,0 +1,18 @@
+-- A candle per symbol, interval and open time, which candle writes upsert on. Of each set of
+-- duplicate candles stored before, the latest revision is kept, then the candle with the most
+-- trades, as a restart may have stored a partial candle before the complete one
+DELETE FROM ohlcs
+WHERE ctid IN (
+    SELECT ctid
+    FROM (
+        SELECT ctid, row_number() OVER (
+            PARTITION BY symbol, interval, open_time
+            ORDER BY revision DESC, trades DESC NULLS LAST, ctid DESC
+        ) AS n
+        FROM ohlcs
+    ) ranked
+    WHERE n > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time ON ohlcs(symbol, interval, open_time);
+DROP INDEX IF EXISTS idx_ohlc_symbol_interval_open_time_desc;
@@ -0,0 +1,18 @@
+-- A footprint per symbol, interval and open time, which footprint writes upsert on. Of each set
+-- of duplicate footprints stored before, the one with the most levels is kept, as a restart may
+-- have stored a partial footprint before the complete one
+DELETE FROM footprints
+WHERE ctid IN (
+    SELECT ctid
+    FROM (
+        SELECT ctid, row_number() OVER (
+            PARTITION BY symbol, interval, open_time
+            ORDER BY jsonb_array_length(levels) DESC NULLS LAST, ctid DESC
+        ) AS n
+        FROM footprints
+    ) ranked
+    WHERE n > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_footprint_symbol_interval_open_time ON footprints(symbol, interval, open_time);
+DROP INDEX IF EXISTS idx_footprint_symbol_interval_open_time_desc;
@@ -0,0 +1,19 @@
+-- A candle per symbol, interval and open time again. Of bars sharing an open time, the one with
+-- the latest first trade is kept; the others are not restored
+DELETE FROM ohlcs
+WHERE ctid IN (
+    SELECT ctid
+    FROM (
+        SELECT ctid, row_number() OVER (
+            PARTITION BY symbol, interval, open_time
+            ORDER BY first_trade_id DESC NULLS LAST, revision DESC, ctid DESC
+        ) AS n
+        FROM ohlcs
+    ) ranked
+    WHERE n > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time ON ohlcs(symbol, interval, open_time);
+DROP INDEX IF EXISTS idx_ohlc_bar_key;
+DROP INDEX IF EXISTS idx_ohlc_candle_key;
+DROP INDEX IF EXISTS idx_ohlc_symbol_interval_open_time_first_trade_id;
@@ -0,0 +1,7 @@
+-- Bars other than time and Heikin-Ashi candles may open in the same millisecond, so they are
+-- unique per symbol, interval, open time and first trade instead, which bar writes upsert on.
+-- Reads order by the same columns
+CREATE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time_first_trade_id ON ohlcs(symbol, interval, open_time, first_trade_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ohlc_candle_key ON ohlcs(symbol, interval, open_time) WHERE bar_type NOT IN ('tick', 'volume', 'dollar', 'range', 'renko');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ohlc_bar_key ON ohlcs(symbol, interval, open_time, first_trade_id) WHERE bar_type IN ('tick', 'volume', 'dollar', 'range', 'renko');
+DROP INDEX IF EXISTS idx_ohlc_symbol_interval_open_time;
@@ -0,0 +1,5 @@
+-- Removes every table, and with them all ticks, candles and footprints
+DROP TABLE IF EXISTS footprints;
+DROP TABLE IF EXISTS ohlc_snapshots;
+DROP TABLE IF EXISTS ohlcs;
+DROP TABLE IF EXISTS ticks;
@@ -0,0 +1,84 @@
+-- Baseline schema, matching the PostgreSQL baseline. Prices and volumes are TEXT so that decimals
+-- keep every digit, which NUMERIC columns would round to floating point, and times are TIMESTAMP
+-- so that they are read back as times
+CREATE TABLE IF NOT EXISTS ticks (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT,
+    price TEXT,
+    quantity TEXT,
+    timestamp TIMESTAMP,
+    trade_id INTEGER,
+    first_trade_id INTEGER,
+    last_trade_id INTEGER,
+    is_buyer_maker BOOLEAN
+);
+
+CREATE INDEX IF NOT EXISTS idx_tick_symbol_timestamp_desc ON ticks(symbol, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_tick_timestamp ON ticks(timestamp);
+
+CREATE TABLE IF NOT EXISTS ohlcs (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT,
+    interval TEXT,
+    bar_type TEXT NOT NULL DEFAULT 'time',
+    open TEXT,
+    high TEXT,
+    low TEXT,
+    close TEXT,
+    volume TEXT,
+    quote_volume TEXT,
+    taker_buy_volume TEXT,
+    taker_buy_quote_volume TEXT,
+    vwap TEXT,
+    trades INTEGER,
+    first_trade_id INTEGER,
+    last_trade_id INTEGER,
+    open_time TIMESTAMP,
+    close_time TIMESTAMP,
+    synthetic BOOLEAN NOT NULL DEFAULT FALSE,
+    revision INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time_desc ON ohlcs(symbol, interval, open_time DESC);
+CREATE INDEX IF NOT EXISTS idx_ohlc_close_time ON ohlcs(close_time);
+
+-- In-progress candles saved on shutdown and periodically, restored on start
+CREATE TABLE IF NOT EXISTS ohlc_snapshots (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT,
+    interval TEXT,
+    bar_type TEXT NOT NULL DEFAULT 'time',
+    open TEXT,
+    high TEXT,
+    low TEXT,
+    close TEXT,
+    volume TEXT,
+    quote_volume TEXT,
+    taker_buy_volume TEXT,
+    taker_buy_quote_volume TEXT,
+    vwap TEXT,
+    trades INTEGER,
+    first_trade_id INTEGER,
+    last_trade_id INTEGER,
+    open_time TIMESTAMP,
+    close_time TIMESTAMP,
+    synthetic BOOLEAN NOT NULL DEFAULT FALSE,
+    revision INTEGER NOT NULL DEFAULT 0
+);
+
+-- Volume profile of each closed candle, with its price levels as a JSON array of
+-- {price, buy_volume, sell_volume} objects
+CREATE TABLE IF NOT EXISTS footprints (
+    id INTEGER PRIMARY KEY,
+    symbol TEXT,
+    interval TEXT,
+    open_time TIMESTAMP,
+    close_time TIMESTAMP,
+    tick_size TEXT,
+    levels TEXT,
+    poc TEXT,
+    value_area_high TEXT,
+    value_area_low TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_footprint_symbol_interval_open_time_desc ON footprints(symbol, interval, open_time DESC);
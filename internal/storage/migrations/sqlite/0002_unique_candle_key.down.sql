@@ -0,0 +1,3 @@
+-- Allows duplicate candles again, which upserting candles relies on not to happen
+CREATE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time_desc ON ohlcs(symbol, interval, open_time DESC);
+DROP INDEX IF EXISTS idx_ohlc_symbol_interval_open_time;
@@ -0,0 +1,4 @@
+-- A candle per symbol, interval and open time, which candle writes upsert on. SQLite databases
+-- were never written without this index, so there are no duplicates to remove
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ohlc_symbol_interval_open_time ON ohlcs(symbol, interval, open_time);
+DROP INDEX IF EXISTS idx_ohlc_symbol_interval_open_time_desc;
@@ -0,0 +1,4 @@
+-- Allows duplicate footprints again, which upserting footprints relies on not to happen. The
+-- duplicates removed by the up migration are not restored
+CREATE INDEX IF NOT EXISTS idx_footprint_symbol_interval_open_time_desc ON footprints(symbol, interval, open_time DESC);
+DROP INDEX IF EXISTS idx_footprint_symbol_interval_open_time;
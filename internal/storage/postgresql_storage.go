@@ -1,12 +1,19 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/azanium/ohlc/internal/candlestick"
 )
@@ -32,18 +39,47 @@ func (e *QueryError) Error() string {
 	return fmt.Sprintf("query for symbol %s from %v to %v failed: %v", e.Symbol, e.Start, e.End, e.Err)
 }
 
-// PostgreSQLStorage implements the candlestick.Storage interface using PostgreSQL
+// candleKey identifies a time or Heikin-Ashi candle or a footprint, enforced by unique indexes that
+// Store and StoreFootprint rely on to upsert
+var candleKey = []clause.Column{{Name: "symbol"}, {Name: "interval"}, {Name: "open_time"}}
+
+// barKey identifies a bar of one of barTypes, several of which may open in the same millisecond
+var barKey = []clause.Column{{Name: "symbol"}, {Name: "interval"}, {Name: "open_time"}, {Name: "first_trade_id"}}
+
+// barTypes lists the bar types stored under barKey, as in the predicates of the partial unique
+// indexes enforcing candleKey and barKey on candles
+const barTypes = "'tick', 'volume', 'dollar', 'range', 'renko'"
+
+// candleConflict returns the upsert of a candle on the partial unique index covering its bar type
+func candleConflict(ohlc *candlestick.OHLC) clause.OnConflict {
+	if ohlc.BarType.IsBar() {
+		return clause.OnConflict{Columns: barKey, TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "bar_type IN (" + barTypes + ")"}}}, UpdateAll: true}
+	}
+	return clause.OnConflict{Columns: candleKey, TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "bar_type NOT IN (" + barTypes + ")"}}}, UpdateAll: true}
+}
+
+// snapshotTable holds the in-progress candles saved by SaveSnapshot, using the ohlcs columns
+const snapshotTable = "ohlc_snapshots"
+
+// PostgreSQLStorage implements the candlestick.Storage interface using PostgreSQL, writing to the
+// master and reading candles, ticks and footprints from the follower if there is one
 type PostgreSQLStorage struct {
-	db *gorm.DB
+	db         *gorm.DB                        // master
+	follower   *gorm.DB                        // nil without a follower
+	maxRetry   int                             // retries of operations failing on the connection
+	aggregates map[candlestick.Interval]string // continuous aggregate of each interval, nil without TimescaleDB
 }
 
-// Store persists an OHLC candlestick
+// Store persists an OHLC candlestick, replacing any candle stored for the same symbol, interval
+// and open time, and for bars first trade, so that reprocessing never duplicates candles
 func (s *PostgreSQLStorage) Store(ohlc *candlestick.OHLC) error {
-	log.Printf("Storing OHLC: symbol=%s, open=%.2f, high=%.2f, low=%.2f, close=%.2f, volume=%.2f, openTime=%s, closeTime=%s",
-		ohlc.Symbol, ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close, ohlc.Volume,
-		ohlc.OpenTime.Format(time.RFC3339), ohlc.CloseTime.Format(time.RFC3339))
+	log.Printf("Storing OHLC: symbol=%s, interval=%s, open=%s, high=%s, low=%s, close=%s, volume=%s, openTime=%s, closeTime=%s, revision=%d",
+		ohlc.Symbol, ohlc.Interval, ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close, ohlc.Volume,
+		ohlc.OpenTime.Format(time.RFC3339), ohlc.CloseTime.Format(time.RFC3339), ohlc.Revision)
 
-	err := s.db.Create(ohlc).Error
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Clauses(candleConflict(ohlc)).Create(ohlc).Error
+	})
 	if err != nil {
 		storageErr := &StorageError{Operation: "store_ohlc", Err: err}
 		log.Printf("Error: %v", storageErr)
@@ -52,10 +88,13 @@ func (s *PostgreSQLStorage) Store(ohlc *candlestick.OHLC) error {
 	return nil
 }
 
-// GetRange retrieves OHLC candlesticks for a symbol within a time range
-func (s *PostgreSQLStorage) GetRange(symbol candlestick.Symbol, start, end time.Time) ([]*candlestick.OHLC, error) {
+// GetRange retrieves the OHLC candlesticks of a symbol and interval opening in [start, end), by
+// ascending open time and first trade, from the interval's continuous aggregate if it has one
+func (s *PostgreSQLStorage) GetRange(symbol candlestick.Symbol, interval candlestick.Interval, start, end time.Time) ([]*candlestick.OHLC, error) {
 	var result []*candlestick.OHLC
-	err := s.db.Where("symbol = ? AND open_time >= ? AND close_time <= ?", symbol, start.UnixMilli(), end.UnixMilli()).Order("open_time ASC").Find(&result).Error
+	err := s.onFollower(func(db *gorm.DB) error {
+		return s.candles(db, symbol, interval).Where("open_time >= ? AND open_time < ?", start, end).Order("open_time ASC, first_trade_id ASC").Find(&result).Error
+	})
 	if err != nil {
 		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
 		log.Printf("Error: %v", queryErr)
@@ -64,9 +103,52 @@ func (s *PostgreSQLStorage) GetRange(symbol candlestick.Symbol, start, end time.
 	return result, nil
 }
 
-// StoreTick persists a tick to the database
+// Query retrieves a page of OHLC candlesticks, continuing after the cursor's open time and first trade
+func (s *PostgreSQLStorage) Query(query candlestick.RangeQuery) (*candlestick.RangePage, error) {
+	queryErr := func(err error) error {
+		queryErr := &QueryError{Symbol: query.Symbol, Start: query.Start, End: query.End, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return queryErr
+	}
+
+	cursor, cursorTradeID, err := query.CursorPosition()
+	if err != nil {
+		return nil, queryErr(err)
+	}
+
+	var result []*candlestick.OHLC
+	err = s.onFollower(func(db *gorm.DB) error {
+		db = s.candles(db, query.Symbol, query.Interval)
+		if !query.Start.IsZero() {
+			db = db.Where("open_time >= ?", query.Start)
+		}
+		if !query.End.IsZero() {
+			db = db.Where("open_time < ?", query.End)
+		}
+		order := "open_time ASC, COALESCE(first_trade_id, 0) ASC"
+		if query.Descending {
+			order = "open_time DESC, COALESCE(first_trade_id, 0) DESC"
+			if !cursor.IsZero() {
+				db = db.Where("(open_time, COALESCE(first_trade_id, 0)) < (?, ?)", cursor, cursorTradeID)
+			}
+		} else if !cursor.IsZero() {
+			db = db.Where("(open_time, COALESCE(first_trade_id, 0)) > (?, ?)", cursor, cursorTradeID)
+		}
+
+		// One candle past the page tells whether another page follows
+		return db.Order(order).Limit(query.PageSize() + 1).Find(&result).Error
+	})
+	if err != nil {
+		return nil, queryErr(err)
+	}
+	return candlestick.NewRangePage(result, query), nil
+}
+
+// StoreTick persists a tick to the database. Tick writes are not retried here: ticks have no
+// natural key, so repeating an insert that reached the database duplicates them, and the tick
+// writer already retries failed batches
 func (s *PostgreSQLStorage) StoreTick(tick *candlestick.Tick) error {
-	log.Printf("Storing tick: symbol=%s, price=%.2f, quantity=%.2f, timestamp=%s",
+	log.Printf("Storing tick: symbol=%s, price=%s, quantity=%s, timestamp=%s",
 		tick.Symbol, tick.Price, tick.Quantity, tick.Timestamp.Format(time.RFC3339))
 
 	err := s.db.Model(&candlestick.Tick{}).Create(tick).Error
@@ -78,21 +160,288 @@ func (s *PostgreSQLStorage) StoreTick(tick *candlestick.Tick) error {
 	return nil
 }
 
-// NewSQLiteStorage creates a new SQLite storage instance
-func NewPostgreSQLStorage(dsn string) (*PostgreSQLStorage, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// tickColumns are the ticks columns written by StoreTicks, in the order of tickRow
+var tickColumns = []string{"symbol", "price", "quantity", "timestamp", "trade_id", "first_trade_id", "last_trade_id", "is_buyer_maker"}
+
+// StoreTicks persists a batch of ticks with a single COPY, which is much cheaper per tick than
+// StoreTick's insert, and is not retried for the same reason
+func (s *PostgreSQLStorage) StoreTicks(ticks []*candlestick.Tick) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+	log.Printf("Storing %d ticks", len(ticks))
+
+	if err := s.copyTicks(context.Background(), ticks); err != nil {
+		storageErr := &StorageError{Operation: "store_ticks", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// copyTicks streams ticks into the ticks table over the pgx connection underneath GORM
+func (s *PostgreSQLStorage) copyTicks(ctx context.Context, ticks []*candlestick.Tick) error {
+	sqlDB, err := s.db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+
+		rows := pgx.CopyFromSlice(len(ticks), func(i int) ([]any, error) {
+			return tickRow(ticks[i]), nil
+		})
+		_, err := pgxConn.Conn().CopyFrom(ctx, pgx.Identifier{"ticks"}, tickColumns, rows)
+		return err
+	})
+}
+
+// tickRow returns the values of tickColumns for a tick
+func tickRow(tick *candlestick.Tick) []any {
+	return []any{
+		string(tick.Symbol),
+		numeric(tick.Price),
+		numeric(tick.Quantity),
+		tick.Timestamp,
+		tick.TradeID,
+		tick.FirstTradeID,
+		tick.LastTradeID,
+		tick.IsBuyerMaker,
+	}
+}
+
+// numeric converts a decimal to the exact PostgreSQL numeric value COPY encodes
+func numeric(d decimal.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+}
+
+// GetTicks retrieves the ticks of a symbol with timestamps in [start, end), in trade order
+func (s *PostgreSQLStorage) GetTicks(symbol candlestick.Symbol, start, end time.Time) ([]*candlestick.Tick, error) {
+	var result []*candlestick.Tick
+	err := s.onFollower(func(db *gorm.DB) error {
+		return db.Where("symbol = ? AND timestamp >= ? AND timestamp < ?", symbol, start, end).Order("timestamp ASC, trade_id ASC").Find(&result).Error
+	})
+	if err != nil {
+		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// OldestTick returns the timestamp of the oldest stored tick, or the zero time without ticks
+func (s *PostgreSQLStorage) OldestTick() (time.Time, error) {
+	var oldest sql.NullTime
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Model(&candlestick.Tick{}).Select("MIN(timestamp)").Row().Scan(&oldest)
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "oldest_tick", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return time.Time{}, storageErr
+	}
+	return oldest.Time, nil
+}
+
+// GetAllTicks retrieves the ticks of every symbol with timestamps in [start, end), in trade order.
+// Like the other retention methods it reads from the master, so that archives hold every tick
+// about to be deleted there
+func (s *PostgreSQLStorage) GetAllTicks(start, end time.Time) ([]*candlestick.Tick, error) {
+	var result []*candlestick.Tick
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Where("timestamp >= ? AND timestamp < ?", start, end).Order("timestamp ASC, trade_id ASC").Find(&result).Error
+	})
+	if err != nil {
+		queryErr := &QueryError{Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// CountTicks counts the ticks with timestamps before before
+func (s *PostgreSQLStorage) CountTicks(before time.Time) (int64, error) {
+	var count int64
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Model(&candlestick.Tick{}).Where("timestamp < ?", before).Count(&count).Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "count_ticks", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return count, nil
+}
+
+// DeleteTicks removes the ticks with timestamps before before, returning how many were removed
+func (s *PostgreSQLStorage) DeleteTicks(before time.Time) (int64, error) {
+	var deleted int64
+	err := s.onMaster(func(db *gorm.DB) error {
+		result := db.Where("timestamp < ?", before).Delete(&candlestick.Tick{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "delete_ticks", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return deleted, nil
+}
+
+// CountCandles counts the candles of an interval opening before before
+func (s *PostgreSQLStorage) CountCandles(interval candlestick.Interval, before time.Time) (int64, error) {
+	var count int64
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Model(&candlestick.OHLC{}).Where("\"interval\" = ? AND open_time < ?", interval, before).Count(&count).Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "count_candles", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return count, nil
+}
+
+// DeleteCandles removes the candles of an interval opening before before, returning how many were
+// removed
+func (s *PostgreSQLStorage) DeleteCandles(interval candlestick.Interval, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.onMaster(func(db *gorm.DB) error {
+		result := db.Where("\"interval\" = ? AND open_time < ?", interval, before).Delete(&candlestick.OHLC{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "delete_candles", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return deleted, nil
+}
+
+// SaveSnapshot replaces the saved in-progress candles with candles
+func (s *PostgreSQLStorage) SaveSnapshot(candles []*candlestick.OHLC) error {
+	log.Printf("Saving snapshot of %d in-progress candles", len(candles))
+
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Table(snapshotTable).Where("1 = 1").Delete(&candlestick.OHLC{}).Error; err != nil {
+				return err
+			}
+			if len(candles) == 0 {
+				return nil
+			}
+			return tx.Table(snapshotTable).Create(candles).Error
+		})
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "save_snapshot", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// LoadSnapshot returns the in-progress candles saved by the last SaveSnapshot call
+func (s *PostgreSQLStorage) LoadSnapshot() ([]*candlestick.OHLC, error) {
+	var result []*candlestick.OHLC
+	if err := s.onMaster(func(db *gorm.DB) error { return db.Table(snapshotTable).Find(&result).Error }); err != nil {
+		storageErr := &StorageError{Operation: "load_snapshot", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return nil, storageErr
+	}
+	return result, nil
+}
+
+// StoreFootprint persists a closed footprint, replacing any footprint stored for the same symbol,
+// interval and open time
+func (s *PostgreSQLStorage) StoreFootprint(footprint *candlestick.Footprint) error {
+	log.Printf("Storing footprint: symbol=%s, interval=%s, levels=%d, poc=%s, valueArea=%s-%s, openTime=%s",
+		footprint.Symbol, footprint.Interval, len(footprint.Levels), footprint.POC, footprint.ValueAreaLow, footprint.ValueAreaHigh,
+		footprint.OpenTime.Format(time.RFC3339))
+
+	err := s.onMaster(func(db *gorm.DB) error {
+		return db.Clauses(clause.OnConflict{Columns: candleKey, UpdateAll: true}).Create(footprint).Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "store_footprint", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// GetFootprints retrieves the footprints of a symbol and interval opening in [start, end)
+func (s *PostgreSQLStorage) GetFootprints(symbol candlestick.Symbol, interval candlestick.Interval, start, end time.Time) ([]*candlestick.Footprint, error) {
+	var result []*candlestick.Footprint
+	err := s.onFollower(func(db *gorm.DB) error {
+		return db.Where("symbol = ? AND \"interval\" = ? AND open_time >= ? AND open_time < ?", symbol, interval, start, end).Order("open_time ASC").Find(&result).Error
+	})
+	if err != nil {
+		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// NewPostgreSQLStorage creates a new PostgreSQL storage instance, applying pending schema migrations if
+// configured and failing if any are pending otherwise. A follower that cannot be reached is left
+// out, reading from the master instead
+func NewPostgreSQLStorage(config PostgreSQLConfig) (*PostgreSQLStorage, error) {
+	db, err := open(config.MasterDSN, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSchema(db, "postgres", config.Migrate); err != nil {
+		return nil, err
+	}
+
+	s := &PostgreSQLStorage{db: db, maxRetry: config.MaxRetry, aggregates: continuousAggregates(db)}
+	if config.FollowerDSN != "" && config.FollowerDSN != config.MasterDSN {
+		if s.follower, err = open(config.FollowerDSN, config); err != nil {
+			log.Printf("Error connecting to follower, reading from master: %v", err)
+		}
 	}
+	return s, nil
+}
 
-	// Auto migrate the schema
-	db.AutoMigrate(&candlestick.OHLC{}, &candlestick.Tick{})
+// Master returns a view of the storage reading from the master as well, for reads that must see
+// the writes made just before, which may not have reached the follower yet
+func (s *PostgreSQLStorage) Master() *PostgreSQLStorage {
+	master := *s
+	master.follower = nil
+	return &master
+}
 
-	return &PostgreSQLStorage{db: db}, nil
+// NewPostgreSQLMigrator creates a migrator of the PostgreSQL database at dsn
+func NewPostgreSQLMigrator(dsn string) (*Migrator, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+	}
+	return NewMigrator(db, "postgres")
 }
 
-// Close closes the database connection
+// Close closes the database connections
 func (s *PostgreSQLStorage) Close() error {
+	if s.follower != nil {
+		if follower, err := s.follower.DB(); err == nil {
+			follower.Close()
+		}
+	}
+
 	db, err := s.db.DB()
 	if err != nil {
 		return err
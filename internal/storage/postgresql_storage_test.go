@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/azanium/ohlc/internal/storage/storagetest"
+	"github.com/shopspring/decimal"
+)
+
+// newBenchmarkStorage connects to the database in OHLC_TEST_DSN, skipping the benchmark without one
+func newBenchmarkStorage(b *testing.B) *PostgreSQLStorage {
+	dsn := os.Getenv("OHLC_TEST_DSN")
+	if dsn == "" {
+		b.Skip("OHLC_TEST_DSN is not set")
+	}
+
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	storage, err := NewPostgreSQLStorage(PostgreSQLConfig{MasterDSN: dsn, Migrate: true})
+	if err != nil {
+		b.Fatalf("Failed to connect: %v", err)
+	}
+	b.Cleanup(func() {
+		storage.db.Where("symbol = ?", "BENCHUSDT").Delete(&candlestick.Tick{})
+		storage.Close()
+	})
+	return storage
+}
+
+// TestPostgreSQLStorageConformance runs the storage conformance tests against the database in
+// OHLC_TEST_DSN, deleting the candles and ticks of the conformance symbol
+func TestPostgreSQLStorageConformance(t *testing.T) {
+	dsn := os.Getenv("OHLC_TEST_DSN")
+	if dsn == "" {
+		t.Skip("OHLC_TEST_DSN is not set")
+	}
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	storagetest.Run(t, func(t *testing.T) candlestick.Storage {
+		storage, err := NewPostgreSQLStorage(PostgreSQLConfig{MasterDSN: dsn, Migrate: true})
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		cleanup := func() {
+			storage.db.Where("symbol = ?", storagetest.Symbol).Delete(&candlestick.OHLC{})
+			storage.db.Where("symbol = ?", storagetest.Symbol).Delete(&candlestick.Tick{})
+		}
+		cleanup()
+		t.Cleanup(func() {
+			cleanup()
+			storage.Close()
+		})
+		return storage
+	})
+}
+
+// TestPostgreSQLStorageContinuousAggregate checks that candles of an interval with a TimescaleDB
+// continuous aggregate are read from it, when OHLC_TEST_DSN is set up with db/timescale.sql
+func TestPostgreSQLStorageContinuousAggregate(t *testing.T) {
+	dsn := os.Getenv("OHLC_TEST_DSN")
+	if dsn == "" {
+		t.Skip("OHLC_TEST_DSN is not set")
+	}
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	storage, err := NewPostgreSQLStorage(PostgreSQLConfig{MasterDSN: dsn, Migrate: true})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer storage.Close()
+	if _, ok := storage.aggregates[candlestick.Interval1h]; !ok {
+		t.Skip("The database has no 1h continuous aggregate")
+	}
+
+	const symbol = "AGGREGATEUSDT"
+	cleanup := func() { storage.db.Where("symbol = ?", symbol).Delete(&candlestick.OHLC{}) }
+	cleanup()
+	defer cleanup()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, price := range []int64{100, 130, 90, 110} {
+		p := decimal.NewFromInt(price)
+		openTime := start.Add(time.Duration(i) * time.Minute)
+		err := storage.Store(&candlestick.OHLC{
+			Symbol: symbol, Interval: candlestick.Interval1m, BarType: candlestick.BarTypeTime,
+			Open: p, High: p, Low: p, Close: p, Volume: decimal.NewFromInt(1), QuoteVolume: p, Trades: 1,
+			OpenTime: openTime, CloseTime: openTime.Add(time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Failed to store candle: %v", err)
+		}
+	}
+
+	candles, err := storage.GetRange(symbol, candlestick.Interval1h, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("Expected 1 candle, got %d", len(candles))
+	}
+	ohlc := candles[0]
+	if ohlc.Interval != candlestick.Interval1h || !ohlc.OpenTime.Equal(start) || !ohlc.CloseTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("Unexpected candle %s from %v to %v", ohlc.Interval, ohlc.OpenTime, ohlc.CloseTime)
+	}
+	if !ohlc.Open.Equal(decimal.NewFromInt(100)) || !ohlc.High.Equal(decimal.NewFromInt(130)) ||
+		!ohlc.Low.Equal(decimal.NewFromInt(90)) || !ohlc.Close.Equal(decimal.NewFromInt(110)) ||
+		!ohlc.Volume.Equal(decimal.NewFromInt(4)) || ohlc.Trades != 4 {
+		t.Errorf("Unexpected aggregate %+v", ohlc)
+	}
+}
+
+// benchmarkTicks returns n ticks of a symbol used only by the benchmarks
+func benchmarkTicks(n int) []*candlestick.Tick {
+	start := time.Now().UTC()
+	ticks := make([]*candlestick.Tick, n)
+	for i := range ticks {
+		id := int64(i)
+		ticks[i] = &candlestick.Tick{
+			Symbol:       "BENCHUSDT",
+			Price:        decimal.New(9712345+id%100, -2),
+			Quantity:     decimal.New(125+id%10, -5),
+			Timestamp:    start.Add(time.Duration(i) * time.Millisecond),
+			TradeID:      id,
+			FirstTradeID: id,
+			LastTradeID:  id,
+			IsBuyerMaker: i%2 == 0,
+		}
+	}
+	return ticks
+}
+
+// BenchmarkStoreTick stores ticks one insert at a time, e.g.
+//
+//	OHLC_TEST_DSN="host=localhost user=demo password=demo123 dbname=ohlc port=5432 sslmode=disable" go test -bench StoreTick ./internal/storage
+func BenchmarkStoreTick(b *testing.B) {
+	storage := newBenchmarkStorage(b)
+	ticks := benchmarkTicks(b.N)
+
+	b.ResetTimer()
+	for _, tick := range ticks {
+		if err := storage.StoreTick(tick); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ticks/s")
+}
+
+// BenchmarkStoreTicks stores ticks with COPY in batches of various sizes
+func BenchmarkStoreTicks(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			storage := newBenchmarkStorage(b)
+			ticks := benchmarkTicks(b.N)
+
+			b.ResetTimer()
+			for start := 0; start < len(ticks); start += size {
+				if err := storage.StoreTicks(ticks[start:min(start+size, len(ticks))]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ticks/s")
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+)
+
+// SQLiteConfig configures a SQLiteStorage
+type SQLiteConfig struct {
+	Path    string // database file, created if missing, or ":memory:" for a database lost on Close
+	Migrate bool   // apply pending schema migrations instead of failing on them
+}
+
+// sqlitePragmas are appended to the path of a SQLite database: writes wait for each other instead
+// of failing, and the write-ahead log lets readers of other processes run alongside the writer
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// SQLiteStorage implements the candlestick.Storage interface using an embedded SQLite database,
+// for single-node deployments and tests that should not need a database server.
+//
+// SQLite compares times as the text they are stored as, which orders correctly only if every time
+// has the same offset, so times are converted to UTC before they are written or compared
+type SQLiteStorage struct {
+	db *gorm.DB
+}
+
+// Store persists an OHLC candlestick, replacing any candle stored for the same symbol, interval
+// and open time, and for bars first trade, so that reprocessing never duplicates candles
+func (s *SQLiteStorage) Store(ohlc *candlestick.OHLC) error {
+	log.Printf("Storing OHLC: symbol=%s, interval=%s, open=%s, high=%s, low=%s, close=%s, volume=%s, openTime=%s, closeTime=%s, revision=%d",
+		ohlc.Symbol, ohlc.Interval, ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close, ohlc.Volume,
+		ohlc.OpenTime.Format(time.RFC3339), ohlc.CloseTime.Format(time.RFC3339), ohlc.Revision)
+
+	err := s.db.Clauses(candleConflict(ohlc)).Create(utcCandle(ohlc)).Error
+	if err != nil {
+		storageErr := &StorageError{Operation: "store_ohlc", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// GetRange retrieves the OHLC candlesticks of a symbol and interval opening in [start, end), by
+// ascending open time and first trade
+func (s *SQLiteStorage) GetRange(symbol candlestick.Symbol, interval candlestick.Interval, start, end time.Time) ([]*candlestick.OHLC, error) {
+	var result []*candlestick.OHLC
+	err := s.db.Where("symbol = ? AND \"interval\" = ? AND open_time >= ? AND open_time < ?", symbol, interval, start.UTC(), end.UTC()).
+		Order("open_time ASC, first_trade_id ASC").Find(&result).Error
+	if err != nil {
+		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// Query retrieves a page of OHLC candlesticks, continuing after the cursor's open time and first trade
+func (s *SQLiteStorage) Query(query candlestick.RangeQuery) (*candlestick.RangePage, error) {
+	queryErr := func(err error) error {
+		queryErr := &QueryError{Symbol: query.Symbol, Start: query.Start, End: query.End, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return queryErr
+	}
+
+	cursor, cursorTradeID, err := query.CursorPosition()
+	if err != nil {
+		return nil, queryErr(err)
+	}
+
+	db := s.db.Where("symbol = ? AND \"interval\" = ?", query.Symbol, query.Interval)
+	if !query.Start.IsZero() {
+		db = db.Where("open_time >= ?", query.Start.UTC())
+	}
+	if !query.End.IsZero() {
+		db = db.Where("open_time < ?", query.End.UTC())
+	}
+	order := "open_time ASC, COALESCE(first_trade_id, 0) ASC"
+	if query.Descending {
+		order = "open_time DESC, COALESCE(first_trade_id, 0) DESC"
+		if !cursor.IsZero() {
+			db = db.Where("(open_time, COALESCE(first_trade_id, 0)) < (?, ?)", cursor.UTC(), cursorTradeID)
+		}
+	} else if !cursor.IsZero() {
+		db = db.Where("(open_time, COALESCE(first_trade_id, 0)) > (?, ?)", cursor.UTC(), cursorTradeID)
+	}
+
+	// One candle past the page tells whether another page follows
+	var result []*candlestick.OHLC
+	if err := db.Order(order).Limit(query.PageSize() + 1).Find(&result).Error; err != nil {
+		return nil, queryErr(err)
+	}
+	return candlestick.NewRangePage(result, query), nil
+}
+
+// StoreTick persists a tick to the database
+func (s *SQLiteStorage) StoreTick(tick *candlestick.Tick) error {
+	log.Printf("Storing tick: symbol=%s, price=%s, quantity=%s, timestamp=%s",
+		tick.Symbol, tick.Price, tick.Quantity, tick.Timestamp.Format(time.RFC3339))
+
+	if err := s.db.Create(utcTick(tick)).Error; err != nil {
+		storageErr := &StorageError{Operation: "store_tick", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// sqliteTickBatch is the most ticks inserted by one statement, keeping its parameters well under
+// SQLite's limit
+const sqliteTickBatch = 500
+
+// StoreTicks persists a batch of ticks in a single transaction
+func (s *SQLiteStorage) StoreTicks(ticks []*candlestick.Tick) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+	log.Printf("Storing %d ticks", len(ticks))
+
+	rows := make([]*candlestick.Tick, len(ticks))
+	for i, tick := range ticks {
+		rows[i] = utcTick(tick)
+	}
+	if err := s.db.CreateInBatches(rows, sqliteTickBatch).Error; err != nil {
+		storageErr := &StorageError{Operation: "store_ticks", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// GetTicks retrieves the ticks of a symbol with timestamps in [start, end), in trade order
+func (s *SQLiteStorage) GetTicks(symbol candlestick.Symbol, start, end time.Time) ([]*candlestick.Tick, error) {
+	var result []*candlestick.Tick
+	err := s.db.Where("symbol = ? AND timestamp >= ? AND timestamp < ?", symbol, start.UTC(), end.UTC()).Order("timestamp ASC, trade_id ASC").Find(&result).Error
+	if err != nil {
+		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// OldestTick returns the timestamp of the oldest stored tick, or the zero time without ticks
+func (s *SQLiteStorage) OldestTick() (time.Time, error) {
+	// MIN would return the text of the timestamp, while the column itself is read back as a time
+	var oldest sql.NullTime
+	err := s.db.Model(&candlestick.Tick{}).Select("timestamp").Order("timestamp ASC").Limit(1).Row().Scan(&oldest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		storageErr := &StorageError{Operation: "oldest_tick", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return time.Time{}, storageErr
+	}
+	return oldest.Time, nil
+}
+
+// GetAllTicks retrieves the ticks of every symbol with timestamps in [start, end), in trade order
+func (s *SQLiteStorage) GetAllTicks(start, end time.Time) ([]*candlestick.Tick, error) {
+	var result []*candlestick.Tick
+	err := s.db.Where("timestamp >= ? AND timestamp < ?", start.UTC(), end.UTC()).Order("timestamp ASC, trade_id ASC").Find(&result).Error
+	if err != nil {
+		queryErr := &QueryError{Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// CountTicks counts the ticks with timestamps before before
+func (s *SQLiteStorage) CountTicks(before time.Time) (int64, error) {
+	var count int64
+	if err := s.db.Model(&candlestick.Tick{}).Where("timestamp < ?", before.UTC()).Count(&count).Error; err != nil {
+		storageErr := &StorageError{Operation: "count_ticks", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return count, nil
+}
+
+// DeleteTicks removes the ticks with timestamps before before, returning how many were removed
+func (s *SQLiteStorage) DeleteTicks(before time.Time) (int64, error) {
+	result := s.db.Where("timestamp < ?", before.UTC()).Delete(&candlestick.Tick{})
+	if result.Error != nil {
+		storageErr := &StorageError{Operation: "delete_ticks", Err: result.Error}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return result.RowsAffected, nil
+}
+
+// CountCandles counts the candles of an interval opening before before
+func (s *SQLiteStorage) CountCandles(interval candlestick.Interval, before time.Time) (int64, error) {
+	var count int64
+	if err := s.db.Model(&candlestick.OHLC{}).Where("\"interval\" = ? AND open_time < ?", interval, before.UTC()).Count(&count).Error; err != nil {
+		storageErr := &StorageError{Operation: "count_candles", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return count, nil
+}
+
+// DeleteCandles removes the candles of an interval opening before before, returning how many were
+// removed
+func (s *SQLiteStorage) DeleteCandles(interval candlestick.Interval, before time.Time) (int64, error) {
+	result := s.db.Where("\"interval\" = ? AND open_time < ?", interval, before.UTC()).Delete(&candlestick.OHLC{})
+	if result.Error != nil {
+		storageErr := &StorageError{Operation: "delete_candles", Err: result.Error}
+		log.Printf("Error: %v", storageErr)
+		return 0, storageErr
+	}
+	return result.RowsAffected, nil
+}
+
+// SaveSnapshot replaces the saved in-progress candles with candles
+func (s *SQLiteStorage) SaveSnapshot(candles []*candlestick.OHLC) error {
+	log.Printf("Saving snapshot of %d in-progress candles", len(candles))
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(snapshotTable).Where("1 = 1").Delete(&candlestick.OHLC{}).Error; err != nil {
+			return err
+		}
+		if len(candles) == 0 {
+			return nil
+		}
+		rows := make([]*candlestick.OHLC, len(candles))
+		for i, ohlc := range candles {
+			rows[i] = utcCandle(ohlc)
+		}
+		return tx.Table(snapshotTable).Create(rows).Error
+	})
+	if err != nil {
+		storageErr := &StorageError{Operation: "save_snapshot", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// LoadSnapshot returns the in-progress candles saved by the last SaveSnapshot call
+func (s *SQLiteStorage) LoadSnapshot() ([]*candlestick.OHLC, error) {
+	var result []*candlestick.OHLC
+	if err := s.db.Table(snapshotTable).Find(&result).Error; err != nil {
+		storageErr := &StorageError{Operation: "load_snapshot", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return nil, storageErr
+	}
+	return result, nil
+}
+
+// StoreFootprint persists a closed footprint, replacing any footprint stored for the same symbol,
+// interval and open time
+func (s *SQLiteStorage) StoreFootprint(footprint *candlestick.Footprint) error {
+	log.Printf("Storing footprint: symbol=%s, interval=%s, levels=%d, poc=%s, valueArea=%s-%s, openTime=%s",
+		footprint.Symbol, footprint.Interval, len(footprint.Levels), footprint.POC, footprint.ValueAreaLow, footprint.ValueAreaHigh,
+		footprint.OpenTime.Format(time.RFC3339))
+
+	row := *footprint
+	row.OpenTime, row.CloseTime = row.OpenTime.UTC(), row.CloseTime.UTC()
+	if err := s.db.Clauses(clause.OnConflict{Columns: candleKey, UpdateAll: true}).Create(&row).Error; err != nil {
+		storageErr := &StorageError{Operation: "store_footprint", Err: err}
+		log.Printf("Error: %v", storageErr)
+		return storageErr
+	}
+	return nil
+}
+
+// GetFootprints retrieves the footprints of a symbol and interval opening in [start, end)
+func (s *SQLiteStorage) GetFootprints(symbol candlestick.Symbol, interval candlestick.Interval, start, end time.Time) ([]*candlestick.Footprint, error) {
+	var result []*candlestick.Footprint
+	err := s.db.Where("symbol = ? AND \"interval\" = ? AND open_time >= ? AND open_time < ?", symbol, interval, start.UTC(), end.UTC()).Order("open_time ASC").Find(&result).Error
+	if err != nil {
+		queryErr := &QueryError{Symbol: symbol, Start: start, End: end, Err: err}
+		log.Printf("Error: %v", queryErr)
+		return nil, queryErr
+	}
+	return result, nil
+}
+
+// utcCandle returns a copy of a candle with its times in UTC
+func utcCandle(ohlc *candlestick.OHLC) *candlestick.OHLC {
+	row := *ohlc
+	row.OpenTime, row.CloseTime = row.OpenTime.UTC(), row.CloseTime.UTC()
+	return &row
+}
+
+// utcTick returns a copy of a tick with its timestamp in UTC
+func utcTick(tick *candlestick.Tick) *candlestick.Tick {
+	row := *tick
+	row.Timestamp = row.Timestamp.UTC()
+	return &row
+}
+
+// openSQLite opens the SQLite database at path over a single connection, which serializes the
+// writes SQLite would otherwise reject while another is in progress and keeps an in-memory
+// database alive until it is closed
+func openSQLite(path string) (*gorm.DB, error) {
+	dsn := path
+	if path != ":memory:" {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		dsn = path + separator + sqlitePragmas
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %v", path, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewSQLiteStorage creates a new SQLite storage instance, applying pending schema migrations if
+// configured and failing if any are pending otherwise
+func NewSQLiteStorage(config SQLiteConfig) (*SQLiteStorage, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("no SQLite database path configured")
+	}
+
+	db, err := openSQLite(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchema(db, "sqlite", config.Migrate); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// NewSQLiteMigrator creates a migrator of the SQLite database at path
+func NewSQLiteMigrator(path string) (*Migrator, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(db, "sqlite")
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/azanium/ohlc/internal/storage/storagetest"
+	"github.com/shopspring/decimal"
+)
+
+// newSQLiteStorage opens a migrated SQLite database in a temporary directory
+func newSQLiteStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	storage, err := NewSQLiteStorage(SQLiteConfig{Path: filepath.Join(t.TempDir(), "ohlc.db"), Migrate: true})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestSQLiteStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) candlestick.Storage { return newSQLiteStorage(t) })
+}
+
+func TestSQLiteStorageTimeZones(t *testing.T) {
+	storage := newSQLiteStorage(t)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	// Candles stored with different offsets are ordered and selected by instant
+	openTime := time.Date(2024, 1, 1, 6, 0, 0, 0, jakarta)
+	local := &candlestick.OHLC{Symbol: "BTCUSDT", Interval: candlestick.Interval1h, Open: decimal.RequireFromString("42000.12345678"), OpenTime: openTime}
+	for _, ohlc := range []*candlestick.OHLC{local, {Symbol: "BTCUSDT", Interval: candlestick.Interval1h, OpenTime: openTime.Add(-time.Hour).UTC()}} {
+		if err := storage.Store(ohlc); err != nil {
+			t.Fatalf("Failed to store candle: %v", err)
+		}
+	}
+	if local.OpenTime.Location() != jakarta {
+		t.Errorf("Expected Store to leave the candle's time zone alone")
+	}
+
+	candles, err := storage.GetRange("BTCUSDT", candlestick.Interval1h, openTime.Add(-time.Hour), openTime.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if len(candles) != 2 || !candles[0].OpenTime.Equal(openTime.Add(-time.Hour)) || !candles[1].OpenTime.Equal(openTime) {
+		t.Fatalf("Expected both candles by open time, got %v", candles)
+	}
+	if !candles[1].Open.Equal(decimal.RequireFromString("42000.12345678")) {
+		t.Errorf("Expected the exact open price, got %s", candles[1].Open)
+	}
+}
+
+func TestSQLiteStorageRetention(t *testing.T) {
+	storage := newSQLiteStorage(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if oldest, err := storage.OldestTick(); err != nil || !oldest.IsZero() {
+		t.Fatalf("Expected no oldest tick, got %v, %v", oldest, err)
+	}
+
+	ticks := make([]*candlestick.Tick, 3)
+	for i := range ticks {
+		ticks[i] = &candlestick.Tick{
+			Symbol:    "BTCUSDT",
+			Price:     decimal.NewFromInt(100),
+			Quantity:  decimal.RequireFromString("0.001"),
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			TradeID:   int64(i),
+		}
+	}
+	if err := storage.StoreTicks(ticks); err != nil {
+		t.Fatalf("Failed to store ticks: %v", err)
+	}
+
+	if oldest, err := storage.OldestTick(); err != nil || !oldest.Equal(start) {
+		t.Errorf("Expected oldest tick at %v, got %v, %v", start, oldest, err)
+	}
+	if all, err := storage.GetAllTicks(start, start.Add(2*time.Hour)); err != nil || len(all) != 2 || !all[0].Quantity.Equal(ticks[0].Quantity) {
+		t.Errorf("Expected the first 2 ticks, got %v, %v", all, err)
+	}
+	if deleted, err := storage.DeleteTicks(start.Add(time.Hour)); err != nil || deleted != 1 {
+		t.Errorf("Expected 1 tick deleted, got %d, %v", deleted, err)
+	}
+	if count, err := storage.CountTicks(start.Add(24 * time.Hour)); err != nil || count != 2 {
+		t.Errorf("Expected 2 ticks left, got %d, %v", count, err)
+	}
+}
+
+func TestSQLiteStorageSnapshot(t *testing.T) {
+	storage := newSQLiteStorage(t)
+	ohlc := &candlestick.OHLC{Symbol: "BTCUSDT", Interval: candlestick.Interval1m, Close: decimal.NewFromInt(100), OpenTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	for i := 0; i < 2; i++ {
+		if err := storage.SaveSnapshot([]*candlestick.OHLC{ohlc}); err != nil {
+			t.Fatalf("Failed to save snapshot: %v", err)
+		}
+	}
+	snapshot, err := storage.LoadSnapshot()
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if len(snapshot) != 1 || !snapshot[0].OpenTime.Equal(ohlc.OpenTime) || !snapshot[0].Close.Equal(ohlc.Close) {
+		t.Errorf("Expected the last snapshot, got %v", snapshot)
+	}
+}
+
+func TestSQLiteStorageFootprints(t *testing.T) {
+	storage := newSQLiteStorage(t)
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	footprint := &candlestick.Footprint{
+		Symbol:   "BTCUSDT",
+		Interval: candlestick.Interval1m,
+		OpenTime: openTime,
+		TickSize: decimal.RequireFromString("0.5"),
+		Levels:   []candlestick.FootprintLevel{{Price: decimal.NewFromInt(100), BuyVolume: decimal.NewFromInt(2)}},
+		POC:      decimal.NewFromInt(100),
+	}
+	if err := storage.StoreFootprint(footprint); err != nil {
+		t.Fatalf("Failed to store footprint: %v", err)
+	}
+
+	footprints, err := storage.GetFootprints("BTCUSDT", candlestick.Interval1m, openTime, openTime.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetFootprints failed: %v", err)
+	}
+	if len(footprints) != 1 || len(footprints[0].Levels) != 1 || !footprints[0].Levels[0].BuyVolume.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("Expected the stored footprint, got %v", footprints)
+	}
+
+	// Storing the footprint again replaces it
+	footprint.Levels = append(footprint.Levels, candlestick.FootprintLevel{Price: decimal.NewFromInt(101), SellVolume: decimal.NewFromInt(1)})
+	if err := storage.StoreFootprint(footprint); err != nil {
+		t.Fatalf("Failed to store footprint again: %v", err)
+	}
+	footprints, err = storage.GetFootprints("BTCUSDT", candlestick.Interval1m, openTime, openTime.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetFootprints failed: %v", err)
+	}
+	if len(footprints) != 1 || len(footprints[0].Levels) != 2 {
+		t.Errorf("Expected the replaced footprint, got %v", footprints)
+	}
+}
+
+func TestSQLiteMigratorRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ohlc.db")
+	if _, err := NewSQLiteStorage(SQLiteConfig{Path: path}); err == nil {
+		t.Fatal("Expected an error for pending migrations")
+	}
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	migrator, err := NewSQLiteMigrator(path)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	if err := migrator.Up(0); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if err := migrator.Down(0); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if err := migrator.Up(0); err != nil {
+		t.Fatalf("Up after Down failed: %v", err)
+	}
+	if version, err := migrator.Version(); err != nil || version != migrator.Latest() {
+		t.Errorf("Expected version %d, got %d, %v", migrator.Latest(), version, err)
+	}
+}
+
+func TestSQLiteStorageRenkoBricks(t *testing.T) {
+	storage := newSQLiteStorage(t)
+	spec := candlestick.BarSpec{Type: candlestick.BarTypeRenko, Threshold: decimal.NewFromInt(1)}
+	agg := candlestick.NewAggregator(candlestick.AggregatorConfig{Bars: map[candlestick.Symbol][]candlestick.BarSpec{"BTCUSDT": {spec}}})
+
+	// The last tick forms five bricks in the millisecond the first brick opened
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var bricks []*candlestick.OHLC
+	for i, price := range []int64{100, 100, 105} {
+		id := int64(i + 1)
+		completed, err := agg.Process(candlestick.Tick{Symbol: "BTCUSDT", Price: decimal.NewFromInt(price), Quantity: decimal.NewFromInt(1), Timestamp: timestamp, FirstTradeID: id, LastTradeID: id})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bricks = append(bricks, completed...)
+	}
+	if len(bricks) != 5 {
+		t.Fatalf("Expected 5 bricks, got %d", len(bricks))
+	}
+	for _, brick := range bricks {
+		if err := storage.Store(brick); err != nil {
+			t.Fatalf("Failed to store brick: %v", err)
+		}
+	}
+
+	// Every brick is read back in order, whole or a page at a time
+	candles, err := storage.GetRange("BTCUSDT", spec.Interval(), timestamp, timestamp.Add(time.Second))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	query := candlestick.RangeQuery{Symbol: "BTCUSDT", Interval: spec.Interval(), Limit: 2}
+	var paged []*candlestick.OHLC
+	for {
+		page, err := storage.Query(query)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		paged = append(paged, page.Candles...)
+		if query.Cursor = page.NextCursor; query.Cursor == "" {
+			break
+		}
+	}
+
+	for _, read := range [][]*candlestick.OHLC{candles, paged} {
+		if len(read) != len(bricks) {
+			t.Fatalf("Expected %d bricks, got %d", len(bricks), len(read))
+		}
+		for i, brick := range read {
+			if !brick.OpenTime.Equal(bricks[i].OpenTime) || !brick.Close.Equal(bricks[i].Close) {
+				t.Errorf("Brick %d: expected close %s at %v, got %s at %v", i, bricks[i].Close, bricks[i].OpenTime, brick.Close, brick.OpenTime)
+			}
+		}
+	}
+}
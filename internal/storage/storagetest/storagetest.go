@@ -0,0 +1,311 @@
+// Package storagetest provides conformance tests shared by the candlestick.Storage implementations
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+	"github.com/shopspring/decimal"
+)
+
+// Symbol is the symbol of the candles stored by the conformance tests, which storages backed by
+// a shared database should clear before each test
+const Symbol candlestick.Symbol = "CONFORMUSDT"
+
+// start is the open time of the first candle stored by the conformance tests
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Run runs the conformance tests against the storages returned by newStorage, which is called once
+// per test and must return a storage without candles of Symbol
+func Run(t *testing.T, newStorage func(t *testing.T) candlestick.Storage) {
+	t.Run("StoreUpsert", func(t *testing.T) { testStoreUpsert(t, newStorage(t)) })
+	t.Run("GetRange", func(t *testing.T) { testGetRange(t, newStorage(t)) })
+	t.Run("GetRangeRevision", func(t *testing.T) { testGetRangeRevision(t, newStorage(t)) })
+	t.Run("StoreBarsSameOpenTime", func(t *testing.T) { testStoreBarsSameOpenTime(t, newStorage(t)) })
+	t.Run("QueryPages", func(t *testing.T) { testQueryPages(t, newStorage(t)) })
+	t.Run("QueryBarsSameOpenTime", func(t *testing.T) { testQueryBarsSameOpenTime(t, newStorage(t)) })
+	t.Run("QueryUnbounded", func(t *testing.T) { testQueryUnbounded(t, newStorage(t)) })
+	t.Run("QueryInvalidCursor", func(t *testing.T) { testQueryInvalidCursor(t, newStorage(t)) })
+}
+
+// storeCandles stores n consecutive one minute candles of Symbol from start, plus a candle of
+// another interval opening at each of the same times, and returns the one minute candles
+func storeCandles(t *testing.T, storage candlestick.Storage, n int) []*candlestick.OHLC {
+	t.Helper()
+
+	candles := make([]*candlestick.OHLC, n)
+	for i := range candles {
+		openTime := start.Add(time.Duration(i) * time.Minute)
+		candles[i] = candle(candlestick.Interval1m, openTime, int64(100+i))
+		if err := storage.Store(candles[i]); err != nil {
+			t.Fatalf("Failed to store candle: %v", err)
+		}
+		if err := storage.Store(candle(candlestick.Interval5m, openTime, 1)); err != nil {
+			t.Fatalf("Failed to store candle: %v", err)
+		}
+	}
+	return candles
+}
+
+// candle returns a closed candle of Symbol with every price at price
+func candle(interval candlestick.Interval, openTime time.Time, price int64) *candlestick.OHLC {
+	p := decimal.NewFromInt(price)
+	return &candlestick.OHLC{
+		Symbol:    Symbol,
+		Interval:  interval,
+		BarType:   candlestick.BarTypeTime,
+		Open:      p,
+		High:      p,
+		Low:       p,
+		Close:     p,
+		Volume:    decimal.NewFromInt(1),
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(interval.Duration()),
+		IsClosed:  true,
+	}
+}
+
+// assertOpenTimes fails the test unless candles open at the given minutes after start, in order
+func assertOpenTimes(t *testing.T, candles []*candlestick.OHLC, minutes ...int) {
+	t.Helper()
+
+	if len(candles) != len(minutes) {
+		t.Fatalf("Expected %d candles, got %d", len(minutes), len(candles))
+	}
+	for i, ohlc := range candles {
+		expected := start.Add(time.Duration(minutes[i]) * time.Minute)
+		if !ohlc.OpenTime.Equal(expected) {
+			t.Errorf("Candle %d: expected open time %v, got %v", i, expected, ohlc.OpenTime)
+		}
+		if ohlc.Symbol != Symbol || ohlc.Interval != candlestick.Interval1m {
+			t.Errorf("Candle %d: unexpected symbol %s or interval %s", i, ohlc.Symbol, ohlc.Interval)
+		}
+	}
+}
+
+// testStoreUpsert checks that storing a candle again replaces it instead of duplicating it
+func testStoreUpsert(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 2)
+	for _, price := range []int64{100, 120} {
+		if err := storage.Store(candle(candlestick.Interval1m, start, price)); err != nil {
+			t.Fatalf("Failed to store candle: %v", err)
+		}
+	}
+
+	candles, err := storage.GetRange(Symbol, candlestick.Interval1m, start, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	assertOpenTimes(t, candles, 0, 1)
+	if !candles[0].Close.Equal(decimal.NewFromInt(120)) {
+		t.Errorf("Expected the last stored close 120, got %s", candles[0].Close)
+	}
+}
+
+// testGetRange checks that GetRange returns candles opening in [start, end) by open time
+func testGetRange(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 5)
+
+	candles, err := storage.GetRange(Symbol, candlestick.Interval1m, start.Add(time.Minute), start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	assertOpenTimes(t, candles, 1, 2)
+	if !candles[0].Close.Equal(decimal.NewFromInt(101)) {
+		t.Errorf("Expected close 101, got %s", candles[0].Close)
+	}
+
+	// Adjacent ranges return every candle exactly once
+	candles, err = storage.GetRange(Symbol, candlestick.Interval1m, start.Add(3*time.Minute), start.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	assertOpenTimes(t, candles, 3, 4)
+
+	candles, err = storage.GetRange(Symbol, candlestick.Interval1m, start.Add(time.Hour), start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if len(candles) != 0 {
+		t.Errorf("Expected no candles, got %d", len(candles))
+	}
+}
+
+// testGetRangeRevision checks that a revised candle replaces the stored one
+func testGetRangeRevision(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 2)
+
+	revision := candle(candlestick.Interval1m, start, 150)
+	revision.Revision = 1
+	if err := storage.Store(revision); err != nil {
+		t.Fatalf("Failed to store revision: %v", err)
+	}
+
+	candles, err := storage.GetRange(Symbol, candlestick.Interval1m, start, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	assertOpenTimes(t, candles, 0, 1)
+	if !candles[0].Close.Equal(decimal.NewFromInt(150)) || candles[0].Revision != 1 {
+		t.Errorf("Expected revision 1 with close 150, got revision %d with close %s", candles[0].Revision, candles[0].Close)
+	}
+}
+
+// renkoInterval is the interval of the bars stored by the conformance tests
+const renkoInterval candlestick.Interval = "renko:1"
+
+// storeBars stores n consecutive Renko bars of Symbol opening at start, with first trades from 10,
+// and returns them
+func storeBars(t *testing.T, storage candlestick.Storage, n int) []*candlestick.OHLC {
+	t.Helper()
+
+	bars := make([]*candlestick.OHLC, n)
+	for i := range bars {
+		bars[i] = candle(renkoInterval, start, int64(100+i))
+		bars[i].BarType = candlestick.BarTypeRenko
+		bars[i].FirstTradeID = int64(10 + i)
+		bars[i].LastTradeID = int64(10 + i)
+		bars[i].CloseTime = start
+		if err := storage.Store(bars[i]); err != nil {
+			t.Fatalf("Failed to store bar: %v", err)
+		}
+	}
+	return bars
+}
+
+// testStoreBarsSameOpenTime checks that consecutive bars opening in the same millisecond are all
+// kept, by first trade, while storing one of them again still replaces it
+func testStoreBarsSameOpenTime(t *testing.T, storage candlestick.Storage) {
+	bars := storeBars(t, storage, 3)
+	revised := *bars[1]
+	revised.Close = decimal.NewFromInt(150)
+	if err := storage.Store(&revised); err != nil {
+		t.Fatalf("Failed to store bar: %v", err)
+	}
+
+	candles, err := storage.GetRange(Symbol, renkoInterval, start, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if len(candles) != len(bars) {
+		t.Fatalf("Expected %d bars, got %d", len(bars), len(candles))
+	}
+	for i, ohlc := range candles {
+		if ohlc.FirstTradeID != bars[i].FirstTradeID || !ohlc.OpenTime.Equal(start) {
+			t.Errorf("Bar %d: expected first trade %d at %v, got %d at %v", i, bars[i].FirstTradeID, start, ohlc.FirstTradeID, ohlc.OpenTime)
+		}
+	}
+	if !candles[1].Close.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("Expected the last stored close 150, got %s", candles[1].Close)
+	}
+}
+
+// testQueryPages checks cursor pagination in both orders
+func testQueryPages(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 7)
+
+	tests := []struct {
+		name       string
+		descending bool
+		pages      [][]int
+	}{
+		{"ascending", false, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"descending", true, [][]int{{5, 4}, {3, 2}, {1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := candlestick.RangeQuery{
+				Symbol:     Symbol,
+				Interval:   candlestick.Interval1m,
+				Start:      start.Add(time.Minute),
+				End:        start.Add(6 * time.Minute),
+				Limit:      2,
+				Descending: tt.descending,
+			}
+			for i, minutes := range tt.pages {
+				page, err := storage.Query(query)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				assertOpenTimes(t, page.Candles, minutes...)
+
+				last := i == len(tt.pages)-1
+				if last != (page.NextCursor == "") {
+					t.Fatalf("Page %d: unexpected next cursor %q", i, page.NextCursor)
+				}
+				query.Cursor = page.NextCursor
+			}
+		})
+	}
+}
+
+// testQueryBarsSameOpenTime checks that pages of bars opening in the same millisecond continue
+// after the last bar of the previous page rather than after its open time
+func testQueryBarsSameOpenTime(t *testing.T, storage candlestick.Storage) {
+	storeBars(t, storage, 5)
+
+	tests := []struct {
+		name       string
+		descending bool
+		pages      [][]int64
+	}{
+		{"ascending", false, [][]int64{{10, 11}, {12, 13}, {14}}},
+		{"descending", true, [][]int64{{14, 13}, {12, 11}, {10}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := candlestick.RangeQuery{Symbol: Symbol, Interval: renkoInterval, Limit: 2, Descending: tt.descending}
+			for i, tradeIDs := range tt.pages {
+				page, err := storage.Query(query)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				if len(page.Candles) != len(tradeIDs) {
+					t.Fatalf("Page %d: expected %d bars, got %d", i, len(tradeIDs), len(page.Candles))
+				}
+				for j, ohlc := range page.Candles {
+					if ohlc.FirstTradeID != tradeIDs[j] {
+						t.Errorf("Page %d bar %d: expected first trade %d, got %d", i, j, tradeIDs[j], ohlc.FirstTradeID)
+					}
+				}
+				query.Cursor = page.NextCursor
+			}
+			if query.Cursor != "" {
+				t.Errorf("Expected no cursor after the last page, got %q", query.Cursor)
+			}
+		})
+	}
+}
+
+// testQueryUnbounded checks that zero bounds and limit select every candle
+func testQueryUnbounded(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 3)
+
+	page, err := storage.Query(candlestick.RangeQuery{Symbol: Symbol, Interval: candlestick.Interval1m})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	assertOpenTimes(t, page.Candles, 0, 1, 2)
+	if page.NextCursor != "" {
+		t.Errorf("Expected no next cursor, got %q", page.NextCursor)
+	}
+
+	page, err = storage.Query(candlestick.RangeQuery{Symbol: Symbol, Interval: candlestick.Interval1m, End: start.Add(2 * time.Minute), Descending: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	assertOpenTimes(t, page.Candles, 1, 0)
+}
+
+// testQueryInvalidCursor checks that a malformed cursor is rejected
+func testQueryInvalidCursor(t *testing.T, storage candlestick.Storage) {
+	storeCandles(t, storage, 1)
+
+	_, err := storage.Query(candlestick.RangeQuery{Symbol: Symbol, Interval: candlestick.Interval1m, Cursor: "not a cursor"})
+	if err == nil {
+		t.Error("Expected an error for an invalid cursor")
+	}
+}
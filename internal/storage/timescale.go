@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/azanium/ohlc/internal/candlestick"
+)
+
+// aggregatePrefix starts the names of the continuous aggregates created by db/timescale.sql, which
+// end with the interval they hold, e.g. ohlcs_1h
+const aggregatePrefix = "ohlcs_"
+
+// continuousAggregates returns the TimescaleDB continuous aggregates of ohlcs by the interval they
+// hold, or nil if the database does not use TimescaleDB
+func continuousAggregates(db *gorm.DB) map[candlestick.Interval]string {
+	var installed bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')").Scan(&installed).Error; err != nil || !installed {
+		return nil
+	}
+
+	var views []string
+	err := db.Raw("SELECT view_name FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = 'ohlcs'").Scan(&views).Error
+	if err != nil {
+		log.Printf("Error listing continuous aggregates: %v", err)
+		return nil
+	}
+
+	aggregates := make(map[candlestick.Interval]string)
+	for _, view := range views {
+		if interval, ok := strings.CutPrefix(view, aggregatePrefix); ok {
+			aggregates[candlestick.Interval(interval)] = view
+		}
+	}
+	log.Printf("Reading candles of intervals with TimescaleDB continuous aggregates: %v", aggregates)
+	return aggregates
+}
+
+// candles returns a query of db over the candles of a symbol and interval, which reads intervals
+// with a continuous aggregate from it instead of ohlcs
+func (s *PostgreSQLStorage) candles(db *gorm.DB, symbol candlestick.Symbol, interval candlestick.Interval) *gorm.DB {
+	if view, ok := s.aggregates[interval]; ok {
+		return db.Table(view).Select("*, ? AS \"interval\", ? AS bar_type", interval, candlestick.BarTypeTime).Where("symbol = ?", symbol)
+	}
+	return db.Model(&candlestick.OHLC{}).Where("symbol = ? AND \"interval\" = ?", symbol, interval)
+}
@@ -1,25 +1,41 @@
 package streaming
 
 import (
+	"context"
 	"sync"
 
 	"github.com/azanium/ohlc/internal/candlestick"
 	"github.com/azanium/ohlc/internal/proto/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// CandleSource provides the in-progress candles served by GetCurrentOHLC
+type CandleSource interface {
+	// Current returns a copy of the in-progress OHLC for a symbol and series, or nil if there is none
+	Current(symbol candlestick.Symbol, interval candlestick.Interval) *candlestick.OHLC
+}
+
 // Service implements the gRPC streaming service
 type Service struct {
 	proto.UnimplementedOHLCServiceServer
+	candles     CandleSource
 	mu          sync.RWMutex
 	subscribers map[candlestick.Symbol][]chan *candlestick.OHLC
+	footprints  map[candlestick.Symbol][]chan *candlestick.Footprint
 	maxChannels int
 	channelSize int
+	precisions  candlestick.Precisions
 }
 
-// NewService creates a new streaming service
-func NewService(maxChannels, channelSize int) *Service {
+// NewService creates a new streaming service, serving in-progress candles from candles and
+// formatting prices and volumes with the per-symbol precisions
+func NewService(maxChannels, channelSize int, candles CandleSource, precisions candlestick.Precisions) *Service {
 	return &Service{
+		candles:     candles,
+		precisions:  precisions,
 		subscribers: make(map[candlestick.Symbol][]chan *candlestick.OHLC),
+		footprints:  make(map[candlestick.Symbol][]chan *candlestick.Footprint),
 		maxChannels: maxChannels,
 		channelSize: channelSize,
 	}
@@ -27,6 +43,16 @@ func NewService(maxChannels, channelSize int) *Service {
 
 // StreamOHLC implements the gRPC streaming endpoint
 func (s *Service) StreamOHLC(req *proto.SubscribeRequest, stream proto.OHLCService_StreamOHLCServer) error {
+	// Only forward the requested intervals, or all of them if none were requested
+	intervals := make(map[candlestick.Interval]bool)
+	for _, intervalStr := range req.Intervals {
+		interval, err := candlestick.ParseSeries(intervalStr)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		intervals[interval] = true
+	}
+
 	// Create channels for each requested symbol
 	channels := make(map[candlestick.Symbol]chan *candlestick.OHLC)
 	for _, symbolStr := range req.Symbols {
@@ -56,19 +82,11 @@ func (s *Service) StreamOHLC(req *proto.SubscribeRequest, stream proto.OHLCServi
 			for _, ch := range channels {
 				select {
 				case ohlc := <-ch:
-					// Convert to proto message
-					msg := &proto.OHLCData{
-						Symbol:    string(ohlc.Symbol),
-						Open:      ohlc.Open,
-						High:      ohlc.High,
-						Low:       ohlc.Low,
-						Close:     ohlc.Close,
-						Volume:    ohlc.Volume,
-						OpenTime:  ohlc.OpenTime.UnixMilli(),
-						CloseTime: ohlc.CloseTime.UnixMilli(),
+					if len(intervals) > 0 && !intervals[ohlc.Interval] {
+						continue
 					}
 
-					if err := stream.Send(msg); err != nil {
+					if err := stream.Send(s.toProto(ohlc)); err != nil {
 						return err
 					}
 				default:
@@ -79,6 +97,80 @@ func (s *Service) StreamOHLC(req *proto.SubscribeRequest, stream proto.OHLCServi
 	}
 }
 
+// GetCurrentOHLC implements the gRPC endpoint returning the in-progress candle
+func (s *Service) GetCurrentOHLC(ctx context.Context, req *proto.CurrentRequest) (*proto.OHLCData, error) {
+	interval, err := candlestick.ParseSeries(req.Interval)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ohlc := s.candles.Current(candlestick.Symbol(req.Symbol), interval)
+	if ohlc == nil {
+		return nil, status.Errorf(codes.NotFound, "no in-progress %s candle for symbol %s", interval, req.Symbol)
+	}
+
+	return s.toProto(ohlc), nil
+}
+
+// StreamFootprint implements the gRPC footprint streaming endpoint
+func (s *Service) StreamFootprint(req *proto.SubscribeRequest, stream proto.OHLCService_StreamFootprintServer) error {
+	intervals := make(map[candlestick.Interval]bool)
+	for _, intervalStr := range req.Intervals {
+		interval, err := candlestick.ParseInterval(intervalStr)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		intervals[interval] = true
+	}
+
+	// A single channel receives the footprints of every requested symbol
+	ch := make(chan *candlestick.Footprint, s.channelSize)
+	symbols := make([]candlestick.Symbol, 0, len(req.Symbols))
+	for _, symbolStr := range req.Symbols {
+		symbol := candlestick.Symbol(symbolStr)
+		symbols = append(symbols, symbol)
+		s.subscribeFootprints(symbol, ch)
+	}
+
+	// Clean up on exit
+	defer func() {
+		for _, symbol := range symbols {
+			s.unsubscribeFootprints(symbol, ch)
+		}
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case footprint := <-ch:
+			if len(intervals) > 0 && !intervals[footprint.Interval] {
+				continue
+			}
+			if err := stream.Send(s.footprintToProto(footprint)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StreamFootprintUpdate broadcasts a footprint update to all footprint subscribers
+func (s *Service) StreamFootprintUpdate(footprint *candlestick.Footprint) error {
+	s.mu.RLock()
+	subscribers := s.footprints[footprint.Symbol]
+	s.mu.RUnlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- footprint:
+		default:
+			// Skip if channel is full
+		}
+	}
+
+	return nil
+}
+
 // Stream broadcasts an OHLC update to all subscribers
 func (s *Service) Stream(ohlc *candlestick.OHLC) error {
 	s.mu.RLock()
@@ -96,6 +188,60 @@ func (s *Service) Stream(ohlc *candlestick.OHLC) error {
 	return nil
 }
 
+// toProto converts an OHLC to its proto message
+func (s *Service) toProto(ohlc *candlestick.OHLC) *proto.OHLCData {
+	precision := s.precisions.For(ohlc.Symbol)
+	return &proto.OHLCData{
+		Symbol:              string(ohlc.Symbol),
+		Interval:            string(ohlc.Interval),
+		BarType:             string(ohlc.BarType),
+		Open:                precision.FormatPrice(ohlc.Open),
+		High:                precision.FormatPrice(ohlc.High),
+		Low:                 precision.FormatPrice(ohlc.Low),
+		Close:               precision.FormatPrice(ohlc.Close),
+		Volume:              precision.FormatQuantity(ohlc.Volume),
+		QuoteVolume:         precision.FormatQuote(ohlc.QuoteVolume),
+		TakerBuyVolume:      precision.FormatQuantity(ohlc.TakerBuyVolume),
+		TakerBuyQuoteVolume: precision.FormatQuote(ohlc.TakerBuyQuoteVolume),
+		Vwap:                precision.FormatPrice(ohlc.VWAP),
+		Trades:              ohlc.Trades,
+		FirstTradeId:        ohlc.FirstTradeID,
+		LastTradeId:         ohlc.LastTradeID,
+		OpenTime:            ohlc.OpenTime.UnixMilli(),
+		CloseTime:           ohlc.CloseTime.UnixMilli(),
+		Synthetic:           ohlc.Synthetic,
+		IsClosed:            ohlc.IsClosed,
+		Revision:            int32(ohlc.Revision),
+		Indicators:          ohlc.Indicators,
+	}
+}
+
+// footprintToProto converts a footprint to its proto message
+func (s *Service) footprintToProto(footprint *candlestick.Footprint) *proto.FootprintData {
+	precision := s.precisions.For(footprint.Symbol)
+	levels := make([]*proto.FootprintLevel, 0, len(footprint.Levels))
+	for _, level := range footprint.Levels {
+		levels = append(levels, &proto.FootprintLevel{
+			Price:      precision.FormatPrice(level.Price),
+			BuyVolume:  precision.FormatQuantity(level.BuyVolume),
+			SellVolume: precision.FormatQuantity(level.SellVolume),
+		})
+	}
+
+	return &proto.FootprintData{
+		Symbol:        string(footprint.Symbol),
+		Interval:      string(footprint.Interval),
+		OpenTime:      footprint.OpenTime.UnixMilli(),
+		CloseTime:     footprint.CloseTime.UnixMilli(),
+		TickSize:      precision.FormatPrice(footprint.TickSize),
+		Levels:        levels,
+		Poc:           precision.FormatPrice(footprint.POC),
+		ValueAreaHigh: precision.FormatPrice(footprint.ValueAreaHigh),
+		ValueAreaLow:  precision.FormatPrice(footprint.ValueAreaLow),
+		IsClosed:      footprint.IsClosed,
+	}
+}
+
 // subscribe adds a subscriber channel for a symbol
 func (s *Service) Subscribe(symbol candlestick.Symbol, ch chan *candlestick.OHLC) {
 	s.mu.Lock()
@@ -117,3 +263,25 @@ func (s *Service) unsubscribe(symbol candlestick.Symbol, ch chan *candlestick.OH
 		}
 	}
 }
+
+// subscribeFootprints adds a footprint subscriber channel for a symbol
+func (s *Service) subscribeFootprints(symbol candlestick.Symbol, ch chan *candlestick.Footprint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.footprints[symbol] = append(s.footprints[symbol], ch)
+}
+
+// unsubscribeFootprints removes a footprint subscriber channel
+func (s *Service) unsubscribeFootprints(symbol candlestick.Symbol, ch chan *candlestick.Footprint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.footprints[symbol]
+	for i, sub := range subs {
+		if sub == ch {
+			s.footprints[symbol] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+}